@@ -1,9 +1,17 @@
 package ast
 
+import (
+	"strings"
+
+	"github.com/jxwr/php-parser/token"
+)
+
 /// Interfaces
 
 type Node interface {
 	Accept(Visitor)
+	Pos() token.Position
+	End() token.Position
 }
 
 type Expression interface {
@@ -25,12 +33,21 @@ const AnyType = String | Integer | Float | Boolean | Null | Resource | Array | O
 
 /// Expression
 
+// BadExpr is a placeholder for an expression that could not be parsed
+// because of a syntax error. It spans the source it stands in for, which may
+// be empty when the expression is missing altogether.
+type BadExpr struct {
+	Span
+}
+
 type Identifier struct {
+	Span
 	Parent Node
 	Value  string
 }
 
 type Variable struct {
+	Span
 	// Name is the identifier for the variable, which may be
 	// a dynamic expression.
 	Name Expression
@@ -38,6 +55,7 @@ type Variable struct {
 }
 
 type BinaryExpression struct {
+	Span
 	Antecedent Expression
 	Subsequent Expression
 	Type       Type
@@ -45,86 +63,137 @@ type BinaryExpression struct {
 }
 
 type TernaryExpression struct {
+	Span
 	Condition, True, False Expression
 	Type                   Type
 }
 
 type UnaryExpression struct {
-	Operand   Expression
-	Operator  string
+	Span
+	Operand  Expression
+	Operator string
+	// Preceding is set when the operand precedes the operator, as in $i++.
 	Preceding bool
 }
 
 type NewExpression struct {
+	Span
 	Class     Expression
 	Arguments []Expression
 }
 
 type AssignmentExpression struct {
+	Span
 	Assignee Assignable
 	Value    Expression
 	Operator string
 }
 
 type FunctionCallExpression struct {
+	Span
 	FunctionName Expression
 	Arguments    []Expression
 }
 
+// CallArgument is an argument of a call that is spread, as in f(...$args),
+// or named, as in f(name: $value). Other arguments appear in argument lists
+// as they are.
+type CallArgument struct {
+	Span
+	Name   string
+	Spread bool
+	Value  Expression
+}
+
 type ConstantExpression struct {
+	Span
 	*Variable
 }
 
 type ArrayExpression struct {
+	Span
 	ArrayType
 	Pairs []ArrayPair
 }
 
 type ArrayPair struct {
+	Span
 	Key   Expression
 	Value Expression
 }
 
 type ArrayLookupExpression struct {
+	Span
 	Array Expression
 	Index Expression
 }
 
 type ArrayAppendExpression struct {
+	Span
 	Array Expression
 }
 
 type Literal struct {
+	Span
 	Type  Type
 	Value string
 }
 
 type ShellCommand struct {
+	Span
 	Command string
 }
 
 type Include struct {
+	Span
+	// Operator is one of include, include_once, require and require_once.
+	Operator    string
 	Expressions []Expression
 }
 
 type PropertyExpression struct {
+	Span
 	Receiver Expression
 	Name     Expression
 	Type     Type
 }
 
 type ClassExpression struct {
+	Span
 	Receiver   Expression
 	Expression Expression
 	Type       Type
 }
 
+// AnonymousFunction is a closure. ByRef is set when it returns a reference,
+// Generator is set when its body yields, and ReturnType is nil when no return
+// type is declared.
 type AnonymousFunction struct {
-	ClosureVariables []FunctionArgument
-	Arguments        []FunctionArgument
+	Span
+	ByRef            bool
+	ClosureVariables []*FunctionArgument
+	Arguments        []*FunctionArgument
+	ReturnType       *TypeExpr
 	Body             *Block
+	Generator        bool
 }
 
+// YieldExpression suspends a generator, producing Value under Key. Key is nil
+// when no key is given, and both are nil for a bare yield.
+type YieldExpression struct {
+	Span
+	Key   Expression
+	Value Expression
+}
+
+// YieldFromExpression suspends a generator while it produces the values of
+// Source, an array or another generator.
+type YieldFromExpression struct {
+	Span
+	Source Expression
+}
+
+func (n BadExpr) exprNode()                {}
 func (n Identifier) exprNode()             {}
 func (n Variable) exprNode()               {}
 func (n BinaryExpression) exprNode()       {}
@@ -143,7 +212,12 @@ func (n ShellCommand) exprNode()           {}
 func (n Literal) exprNode()                {}
 func (n Include) exprNode()                {}
 func (n AnonymousFunction) exprNode()      {}
+func (n YieldExpression) exprNode()        {}
+func (n YieldFromExpression) exprNode()    {}
+func (n MethodCallExpression) exprNode()   {}
+func (n CallArgument) exprNode()           {}
 
+func (n *BadExpr) Accept(v Visitor)                { v.VisitBadExpr(n) }
 func (n *Identifier) Accept(v Visitor)             { v.VisitIdentifier(n) }
 func (n *Variable) Accept(v Visitor)               { v.VisitVariable(n) }
 func (n *BinaryExpression) Accept(v Visitor)       { v.VisitBinaryExpression(n) }
@@ -162,107 +236,260 @@ func (n *ShellCommand) Accept(v Visitor)           { v.VisitShellCommand(n) }
 func (n *Literal) Accept(v Visitor)                { v.VisitLiteral(n) }
 func (n *Include) Accept(v Visitor)                { v.VisitInclude(n) }
 func (n *AnonymousFunction) Accept(v Visitor)      { v.VisitAnonymousFunction(n) }
+func (n *YieldExpression) Accept(v Visitor)        { v.VisitYieldExpression(n) }
+func (n *YieldFromExpression) Accept(v Visitor)    { v.VisitYieldFromExpression(n) }
+func (n *MethodCallExpression) Accept(v Visitor)   { v.VisitMethodCallExpression(n) }
+func (n *CallArgument) Accept(v Visitor)           { v.VisitCallArgument(n) }
 
 /// Statements
 
+// BadStmt is a placeholder for a statement that could not be parsed because
+// of a syntax error. It spans the source skipped to recover from the error.
+type BadStmt struct {
+	Span
+}
+
 type GlobalDeclaration struct {
+	Span
 	Identifiers []*Variable
 }
 
 type EmptyStatement struct {
+	Span
 }
 
 type ExpressionStmt struct {
+	Span
 	Expression
 }
 
 type EchoStmt struct {
+	Span
 	Expressions []Expression
 }
 
 type ReturnStmt struct {
+	Span
 	Expression
 }
 
 type BreakStmt struct {
+	Span
 	Expression
 }
 
 type ContinueStmt struct {
+	Span
 	Expression
 }
 
 type ThrowStmt struct {
+	Span
 	Expression
 }
 
 type IncludeStmt struct {
+	Span
 	Include
 }
 
 type ExitStmt struct {
+	Span
 	Expression Expression
 }
 
 type FunctionCallStmt struct {
+	Span
 	FunctionCallExpression
 }
 
 type Block struct {
+	Span
 	Statements []Statement
 	Scope      Scope
 }
 
+// FunctionStmt declares a function, or holds the declaration of a method.
+// Generator is set when its body yields.
 type FunctionStmt struct {
+	Span
 	*FunctionDefinition
-	Body *Block
+	Body      *Block
+	Doc       string
+	Generator bool
 }
 
+// FunctionDefinition is the signature of a function. ByRef is set when the
+// function returns a reference, and ReturnType is nil when no return type is
+// declared.
 type FunctionDefinition struct {
-	Name      string
-	Arguments []FunctionArgument
+	Span
+	Name       string
+	ByRef      bool
+	Arguments  []*FunctionArgument
+	ReturnType *TypeExpr
 }
 
+// FunctionArgument is a parameter of a function, or a variable a closure
+// uses. TypeHint is nil when no type is declared. ByRef is set for a
+// parameter passed by reference, and Variadic for one collecting the
+// remaining arguments.
 type FunctionArgument struct {
-	TypeHint string
+	Span
+	TypeHint *TypeExpr
+	ByRef    bool
+	Variadic bool
 	Default  Expression
 	Variable *Variable
 }
 
+type TypeForm int
+
+const (
+	NamedType TypeForm = iota
+	NullableType
+	UnionType
+	IntersectionType
+)
+
+func (f TypeForm) String() string {
+	switch f {
+	case NullableType:
+		return "nullable"
+	case UnionType:
+		return "union"
+	case IntersectionType:
+		return "intersection"
+	}
+	return "named"
+}
+
+// TypeExpr is a type declared for a parameter, a property or the return
+// value of a function. A named type has the name of a class or of a built in
+// type such as int, mixed, static or void in Name. The other forms combine
+// Types: a nullable type ?T has T as its only element, and unions and
+// intersections list their members.
+type TypeExpr struct {
+	Span
+	Form  TypeForm
+	Name  string
+	Types []*TypeExpr
+}
+
+// String returns the type as it is written in PHP.
+func (t *TypeExpr) String() string {
+	switch t.Form {
+	case NullableType:
+		if len(t.Types) == 1 {
+			return "?" + t.Types[0].String()
+		}
+	case UnionType, IntersectionType:
+		sep := "|"
+		if t.Form == IntersectionType {
+			sep = "&"
+		}
+		list := make([]string, len(t.Types))
+		for i, typ := range t.Types {
+			list[i] = typ.String()
+			if t.Form == UnionType && typ.Form == IntersectionType {
+				list[i] = "(" + list[i] + ")"
+			}
+		}
+		return strings.Join(list, sep)
+	}
+	return t.Name
+}
+
 type Class struct {
+	Span
+	Doc        string
 	Name       string
+	Abstract   bool
+	Final      bool
 	Extends    string
 	Implements []string
-	Methods    []Method
-	Properties []Property
-	Constants  []Constant
+	TraitUses  []*TraitUse
+	Methods    []*Method
+	Properties []*Property
+	Constants  []*Constant
 }
 
 type Constant struct {
+	Span
 	*Variable
 	Value interface{}
+	Doc   string
 }
 
 type Interface struct {
+	Span
+	Doc       string
 	Name      string
 	Inherits  []string
-	Methods   []Method
-	Constants []Constant
+	Methods   []*Method
+	Constants []*Constant
 }
 
+// Trait declares a trait, whose members are copied into the classes that
+// use it.
+type Trait struct {
+	Span
+	Doc        string
+	Name       string
+	TraitUses  []*TraitUse
+	Methods    []*Method
+	Properties []*Property
+	Constants  []*Constant
+}
+
+// TraitUse is a use declaration in the body of a class or trait, copying in
+// the members of Traits. Rules holds the statements of its conflict
+// resolution block, if it has one.
+type TraitUse struct {
+	Span
+	Traits []string
+	Rules  []*TraitRule
+}
+
+// TraitRule is a statement of the conflict resolution block of a trait use.
+// It is either Trait::Method insteadof InsteadOf..., picking the method of
+// Trait over those of the other traits, or [Trait::]Method as [visibility]
+// [Alias], giving the method another name or visibility. Visibility is only
+// meaningful when ChangesVisibility is set.
+type TraitRule struct {
+	Span
+	Trait             string
+	Method            string
+	InsteadOf         []string
+	ChangesVisibility bool
+	Visibility        Visibility
+	Alias             string
+}
+
+// Property is a property of a class. TypeHint is its declared type, or nil
+// when none is declared, while Type is the type inferred for it.
 type Property struct {
+	Span
+	Doc            string
 	Name           string
 	Visibility     Visibility
+	Static         bool
+	TypeHint       *TypeExpr
 	Type           Type
 	Initialization Expression
 }
 
 type Method struct {
+	Span
 	*FunctionStmt
 	Visibility Visibility
+	Static     bool
+	Final      bool
+	Abstract   bool
 }
 
 type MethodCallExpression struct {
+	Span
 	Receiver Expression
 	*FunctionCallExpression
 }
@@ -275,24 +502,38 @@ const (
 	Public
 )
 
+func (v Visibility) String() string {
+	switch v {
+	case Private:
+		return "private"
+	case Protected:
+		return "protected"
+	}
+	return "public"
+}
+
 type IfStmt struct {
+	Span
 	Condition   Expression
 	TrueBranch  Statement
 	FalseBranch Statement
 }
 
 type SwitchStmt struct {
+	Span
 	Expression  Expression
 	Cases       []*SwitchCase
 	DefaultCase *Block
 }
 
 type SwitchCase struct {
+	Span
 	Expression Expression
 	Block      Block
 }
 
 type ForStmt struct {
+	Span
 	Initialization []Expression
 	Termination    []Expression
 	Iteration      []Expression
@@ -300,50 +541,119 @@ type ForStmt struct {
 }
 
 type WhileStmt struct {
+	Span
 	Termination Expression
 	LoopBlock   Statement
 }
 
 type DoWhileStmt struct {
+	Span
 	Termination Expression
 	LoopBlock   Statement
 }
 
 type TryStmt struct {
+	Span
 	TryBlock     *Block
 	FinallyBlock *Block
 	CatchStmts   []*CatchStmt
 }
 
+// CatchStmt is a catch clause of a try statement. CatchType lists the
+// classes it catches, and CatchVar is nil when the exception is not
+// assigned to a variable.
 type CatchStmt struct {
+	Span
 	CatchBlock *Block
-	CatchType  string
+	CatchType  []string
 	CatchVar   *Variable
 }
 
+// ForeachStmt is a foreach loop. KeyByRef and ValueByRef are set when the
+// key or value is taken by reference, as in foreach ($a as &$v).
 type ForeachStmt struct {
-	Source    Expression
-	Key       *Variable
-	Value     *Variable
-	LoopBlock Statement
+	Span
+	Source     Expression
+	Key        *Variable
+	KeyByRef   bool
+	Value      *Variable
+	ValueByRef bool
+	LoopBlock  Statement
 }
 
 // list($a, $b, $c) = $my_array;
 type ListStatement struct {
+	Span
+	// Assignees holds nil for each element skipped, as in list(, $b).
 	Assignees []Assignable
 	Value     Expression
 	Operator  string
 }
 
 type StaticVariableDeclaration struct {
+	Span
 	Declarations []Expression
 }
 
 type DeclareBlock struct {
+	Span
 	Statements   *Block
 	Declarations []string
 }
 
+// NamespaceStmt declares the namespace of the statements that follow it, or
+// only of those in Block when the braced form is used.
+type NamespaceStmt struct {
+	Span
+	Name  string
+	Block *Block
+}
+
+type UseType int
+
+const (
+	UseClass UseType = iota
+	UseFunction
+	UseConst
+)
+
+func (t UseType) String() string {
+	switch t {
+	case UseFunction:
+		return "function"
+	case UseConst:
+		return "const"
+	}
+	return "class"
+}
+
+// UseStmt imports names into the current namespace. Prefix is set when the
+// grouped form use A\{B, C} is used.
+type UseStmt struct {
+	Span
+	Type   UseType
+	Prefix string
+	Uses   []*UseClause
+}
+
+// UseClause is a single imported name. Name is fully qualified, including any
+// group prefix.
+type UseClause struct {
+	Span
+	Type  UseType
+	Name  string
+	Alias string
+}
+
+// LocalName returns the name the import is known by in the current namespace.
+func (u *UseClause) LocalName() string {
+	if u.Alias != "" {
+		return u.Alias
+	}
+	return u.Name[strings.LastIndex(u.Name, "\\")+1:]
+}
+
+func (n BadStmt) stmtNode()                   {}
 func (n GlobalDeclaration) stmtNode()         {}
 func (n ExpressionStmt) stmtNode()            {}
 func (n EmptyStatement) stmtNode()            {}
@@ -360,6 +670,8 @@ func (n FunctionDefinition) stmtNode()        {}
 func (n Interface) stmtNode()                 {}
 func (n DeclareBlock) stmtNode()              {}
 func (n Class) stmtNode()                     {}
+func (n Trait) stmtNode()                     {}
+func (n TraitUse) stmtNode()                  {}
 func (n Method) stmtNode()                    {}
 func (n Block) stmtNode()                     {}
 func (n IfStmt) stmtNode()                    {}
@@ -372,7 +684,10 @@ func (n CatchStmt) stmtNode()                 {}
 func (n ForeachStmt) stmtNode()               {}
 func (n ListStatement) stmtNode()             {}
 func (n StaticVariableDeclaration) stmtNode() {}
+func (n NamespaceStmt) stmtNode()             {}
+func (n UseStmt) stmtNode()                   {}
 
+func (n *BadStmt) Accept(v Visitor)            { v.VisitBadStmt(n) }
 func (n *GlobalDeclaration) Accept(v Visitor)  { v.VisitGlobalDeclaration(n) }
 func (n *ExpressionStmt) Accept(v Visitor)     { v.VisitExpressionStmt(n) }
 func (n *EmptyStatement) Accept(v Visitor)     { v.VisitEmptyStatement(n) }
@@ -389,6 +704,8 @@ func (n *FunctionDefinition) Accept(v Visitor) { v.VisitFunctionDefinition(n) }
 func (n *Interface) Accept(v Visitor)          { v.VisitInterface(n) }
 func (n *DeclareBlock) Accept(v Visitor)       { v.VisitDeclareBlock(n) }
 func (n *Class) Accept(v Visitor)              { v.VisitClass(n) }
+func (n *Trait) Accept(v Visitor)              { v.VisitTrait(n) }
+func (n *TraitUse) Accept(v Visitor)           { v.VisitTraitUse(n) }
 func (n *Method) Accept(v Visitor)             { v.VisitMethod(n) }
 func (n *Block) Accept(v Visitor)              { v.VisitBlock(n) }
 func (n *IfStmt) Accept(v Visitor)             { v.VisitIfStmt(n) }
@@ -403,3 +720,25 @@ func (n *ListStatement) Accept(v Visitor)      { v.VisitListStatement(n) }
 func (n *StaticVariableDeclaration) Accept(v Visitor) {
 	v.VisitStaticVariableDeclaration(n)
 }
+func (n *NamespaceStmt) Accept(v Visitor) { v.VisitNamespaceStmt(n) }
+func (n *UseStmt) Accept(v Visitor)       { v.VisitUseStmt(n) }
+
+// The parts of declarations are nodes as well, although they are neither
+// statements nor expressions.
+func (n *FunctionArgument) Accept(v Visitor) { v.VisitFunctionArgument(n) }
+func (n *TypeExpr) Accept(v Visitor)         { v.VisitTypeExpr(n) }
+func (n *Constant) Accept(v Visitor)         { v.VisitConstant(n) }
+func (n *Property) Accept(v Visitor)         { v.VisitProperty(n) }
+
+// Statements embedding an Expression would otherwise have ambiguous Pos and
+// End methods; their own span covers the keyword and terminator as well.
+func (n *ExpressionStmt) Pos() token.Position { return n.Span.Pos() }
+func (n *ExpressionStmt) End() token.Position { return n.Span.End() }
+func (n *ReturnStmt) Pos() token.Position     { return n.Span.Pos() }
+func (n *ReturnStmt) End() token.Position     { return n.Span.End() }
+func (n *BreakStmt) Pos() token.Position      { return n.Span.Pos() }
+func (n *BreakStmt) End() token.Position      { return n.Span.End() }
+func (n *ContinueStmt) Pos() token.Position   { return n.Span.Pos() }
+func (n *ContinueStmt) End() token.Position   { return n.Span.End() }
+func (n *ThrowStmt) Pos() token.Position      { return n.Span.Pos() }
+func (n *ThrowStmt) End() token.Position      { return n.Span.End() }
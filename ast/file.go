@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jxwr/php-parser/token"
+)
+
+// File is a parsed source file that keeps every token of its source,
+// whitespace and comments included, alongside its nodes. It is what
+// Parser.ParseFile returns, and lets the printer reproduce untouched parts
+// of the source exactly.
+type File struct {
+	Nodes  []Node
+	Tokens []token.Item
+
+	// original records how each node looked when the file was parsed.
+	original map[Node]string
+	nodes    string
+}
+
+// NewFile returns a file made up of nodes and the tokens they were parsed
+// from, taking note of the current state of every node so that later
+// changes to them can be detected.
+func NewFile(nodes []Node, tokens []token.Item) *File {
+	f := &File{
+		Nodes:    nodes,
+		Tokens:   tokens,
+		original: make(map[Node]string),
+		nodes:    fingerprint(nodes),
+	}
+	for _, n := range nodes {
+		Inspect(n, func(n Node) bool {
+			if n != nil {
+				f.original[n] = fingerprint(n)
+			}
+			return true
+		})
+	}
+	return f
+}
+
+// fingerprint describes the fields of a node, identifying its children by
+// address, so that two fingerprints of the same node differ if the node has
+// been changed or given different children in between. The Go syntax format
+// is used because it does not call String methods, which for Type depends on
+// map iteration order.
+func fingerprint(v interface{}) string {
+	return fmt.Sprintf("%#v", v)
+}
+
+// Modified reports whether n has been changed since the file was parsed,
+// either in its own fields or in which nodes are its children. Nodes that
+// were not part of the file when it was parsed are always modified. Changes
+// further down the tree only count as changes to the nodes they were made
+// to.
+func (f *File) Modified(n Node) bool {
+	original, ok := f.original[n]
+	return !ok || original != fingerprint(n)
+}
+
+// NodesModified reports whether nodes have been added to, removed from or
+// rearranged in Nodes since the file was parsed.
+func (f *File) NodesModified() bool {
+	return f.nodes != fingerprint(f.Nodes)
+}
+
+// TokensOf returns the tokens of the source n was parsed from, or nil for a
+// node with no position.
+func (f *File) TokensOf(n Node) []token.Item {
+	if n.Pos().Line == 0 {
+		return nil
+	}
+	return f.TokensBetween(n.Pos(), n.End())
+}
+
+// TokensBetween returns the tokens starting at or after begin and ending by
+// end.
+func (f *File) TokensBetween(begin, end token.Position) []token.Item {
+	i := sort.Search(len(f.Tokens), func(i int) bool {
+		return f.Tokens[i].Begin.Position >= begin.Position
+	})
+	j := i
+	for j < len(f.Tokens) && f.Tokens[j].End.Position <= end.Position && f.Tokens[j].Typ != token.EOF {
+		j++
+	}
+	return f.Tokens[i:j]
+}
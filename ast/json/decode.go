@@ -0,0 +1,229 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+// kinds maps the kind of each object to the type of the ast package it is
+// decoded to.
+var kinds = make(map[string]reflect.Type)
+
+func init() {
+	for _, v := range []interface{}{
+		ast.BadExpr{}, ast.Identifier{}, ast.Variable{},
+		ast.BinaryExpression{}, ast.TernaryExpression{},
+		ast.UnaryExpression{}, ast.NewExpression{},
+		ast.AssignmentExpression{}, ast.FunctionCallExpression{},
+		ast.ConstantExpression{}, ast.ArrayExpression{}, ast.ArrayPair{},
+		ast.ArrayLookupExpression{}, ast.ArrayAppendExpression{},
+		ast.Literal{}, ast.ShellCommand{}, ast.Include{},
+		ast.PropertyExpression{}, ast.ClassExpression{},
+		ast.AnonymousFunction{}, ast.MethodCallExpression{},
+		ast.CallArgument{},
+		ast.YieldExpression{}, ast.YieldFromExpression{},
+
+		ast.BadStmt{}, ast.GlobalDeclaration{}, ast.EmptyStatement{},
+		ast.ExpressionStmt{}, ast.EchoStmt{}, ast.ReturnStmt{},
+		ast.BreakStmt{}, ast.ContinueStmt{}, ast.ThrowStmt{},
+		ast.IncludeStmt{}, ast.ExitStmt{}, ast.FunctionCallStmt{},
+		ast.Block{}, ast.FunctionStmt{}, ast.FunctionDefinition{},
+		ast.FunctionArgument{}, ast.TypeExpr{}, ast.Class{}, ast.Trait{},
+		ast.TraitUse{}, ast.TraitRule{}, ast.Constant{},
+		ast.Interface{}, ast.Property{}, ast.Method{}, ast.IfStmt{},
+		ast.SwitchStmt{}, ast.SwitchCase{}, ast.ForStmt{},
+		ast.WhileStmt{}, ast.DoWhileStmt{}, ast.TryStmt{},
+		ast.CatchStmt{}, ast.ForeachStmt{}, ast.ListStatement{},
+		ast.StaticVariableDeclaration{}, ast.DeclareBlock{},
+		ast.NamespaceStmt{}, ast.UseStmt{}, ast.UseClause{},
+	} {
+		t := reflect.TypeOf(v)
+		kinds[t.Name()] = t
+	}
+}
+
+// Unmarshal decodes a tree encoded by Marshal.
+func Unmarshal(data []byte) (ast.Node, error) {
+	var n ast.Node
+	err := unmarshal(data, reflect.ValueOf(&n).Elem())
+	return n, err
+}
+
+// UnmarshalNodes decodes a list of trees encoded by MarshalNodes.
+func UnmarshalNodes(data []byte) ([]ast.Node, error) {
+	var nodes []ast.Node
+	err := unmarshal(data, reflect.ValueOf(&nodes).Elem())
+	return nodes, err
+}
+
+func unmarshal(data []byte, v reflect.Value) error {
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	d := &decoder{ids: make(map[int64]reflect.Value)}
+	return d.set(v, raw)
+}
+
+type decoder struct {
+	// ids maps the id of each shared node decoded so far to a pointer to
+	// it.
+	ids map[int64]reflect.Value
+}
+
+// set decodes raw, as decoded by the standard library, into v.
+func (d *decoder) set(v reflect.Value, raw interface{}) error {
+	if raw == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		ptr, err := d.node(raw)
+		if err != nil {
+			return err
+		}
+		if !ptr.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("json: cannot use %s as %s", ptr.Elem().Type().Name(), v.Type())
+		}
+		v.Set(ptr)
+	case reflect.Struct:
+		ptr, err := d.node(raw)
+		if err != nil {
+			return err
+		}
+		if ptr.Elem().Type() != v.Type() {
+			return fmt.Errorf("json: cannot use %s as %s", ptr.Elem().Type().Name(), v.Type())
+		}
+		v.Set(ptr.Elem())
+	case reflect.Slice:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("json: expected an array for %s", v.Type())
+		}
+		s := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, elem := range list {
+			if err := d.set(s.Index(i), elem); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("json: expected a string for %s", v.Type())
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("json: expected a boolean for %s", v.Type())
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := d.integer(v.Type(), raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	default:
+		return fmt.Errorf("json: cannot decode %s", v.Type())
+	}
+	return nil
+}
+
+func (d *decoder) integer(t reflect.Type, raw interface{}) (int64, error) {
+	if names, ok := enums[t]; ok {
+		s, ok := raw.(string)
+		if !ok {
+			return 0, fmt.Errorf("json: expected a string for %s", t)
+		}
+		return names.parse(s)
+	}
+	n, ok := raw.(stdjson.Number)
+	if !ok {
+		return 0, fmt.Errorf("json: expected a number for %s", t)
+	}
+	return n.Int64()
+}
+
+// node decodes an object into a new value of the type its kind names,
+// returning a pointer to it.
+func (d *decoder) node(raw interface{}) (reflect.Value, error) {
+	o, ok := raw.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("json: expected an object for a node")
+	}
+	if ref, ok := o["ref"]; ok {
+		id, err := d.integer(reflect.TypeOf(0), ref)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr, ok := d.ids[id]
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("json: reference to unknown node %d", id)
+		}
+		return ptr, nil
+	}
+	kind, _ := o["kind"].(string)
+	t, ok := kinds[kind]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("json: unknown node kind %q", kind)
+	}
+	ptr := reflect.New(t)
+	if raw, ok := o["id"]; ok {
+		id, err := d.integer(reflect.TypeOf(0), raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		d.ids[id] = ptr
+	}
+	v := ptr.Elem()
+	if span := v.FieldByName("Span"); span.IsValid() && span.Type() == spanType {
+		begin, err := d.position(o["pos"])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		end, err := d.position(o["end"])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		span.Set(reflect.ValueOf(ast.Span{StartPos: begin, EndPos: end}))
+	}
+	var err error
+	eachField(v, func(name string, f reflect.Value) {
+		if raw, ok := o[name]; ok && err == nil {
+			err = d.set(f, raw)
+		}
+	})
+	return ptr, err
+}
+
+func (d *decoder) position(raw interface{}) (token.Position, error) {
+	var p token.Position
+	if raw == nil {
+		return p, nil
+	}
+	o, ok := raw.(map[string]interface{})
+	if !ok {
+		return p, fmt.Errorf("json: expected an object for a position")
+	}
+	for name, dst := range map[string]*int{"line": &p.Line, "column": &p.Column, "offset": &p.Position} {
+		if raw, ok := o[name]; ok {
+			i, err := d.integer(reflect.TypeOf(0), raw)
+			if err != nil {
+				return p, err
+			}
+			*dst = int(i)
+		}
+	}
+	p.File, _ = o["file"].(string)
+	return p, nil
+}
@@ -0,0 +1,219 @@
+// Package json converts syntax trees to and from JSON, so that they can be
+// cached or handed to tools not written in Go.
+//
+// Every node is encoded as an object whose "kind" member names its Go type,
+// such as "BinaryExpression", followed by its position as "pos" and "end"
+// and by each of its fields under the field name with a lower case initial.
+// Embedded nodes appear as fields too, so a FunctionStmt has a
+// "functionDefinition" member. Positions are objects with "line", "column",
+// "offset" and, when known, "file" members. Types, visibilities and use
+// types are encoded as their PHP names, with the names of a type combining
+// several separated by "|".
+//
+// A node appearing more than once in a tree, like the condition of a
+// ternary without a middle operand, is given an "id" member where it first
+// appears and is replaced by an object with a matching "ref" member
+// wherever else it does. The Parent of an identifier and the Scope of a
+// block are left out, as they hold the results of analysis rather than
+// syntax.
+package json
+
+import (
+	stdjson "encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+// Marshal returns the JSON encoding of the tree rooted at n.
+func Marshal(n ast.Node) ([]byte, error) {
+	e := newEncoder()
+	e.count(reflect.ValueOf(n))
+	return stdjson.Marshal(e.value(reflect.ValueOf(&n).Elem()))
+}
+
+// MarshalNodes returns the JSON encoding of a list of trees, such as those
+// returned by Parser.Parse, as an array.
+func MarshalNodes(nodes []ast.Node) ([]byte, error) {
+	e := newEncoder()
+	v := reflect.ValueOf(nodes)
+	e.count(v)
+	return stdjson.Marshal(e.value(v))
+}
+
+// member is a name and value pair of a JSON object.
+type member struct {
+	name  string
+	value interface{}
+}
+
+// object is a JSON object that keeps its members in order.
+type object []member
+
+func (o object) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, m := range o {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		name, err := stdjson.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := stdjson.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, name...), ':'), value...)
+	}
+	return append(buf, '}'), nil
+}
+
+type encoder struct {
+	// seen counts the times each node is reached, and ids numbers those
+	// reached more than once as they are first encoded.
+	seen map[interface{}]int
+	ids  map[interface{}]int
+}
+
+func newEncoder() *encoder {
+	return &encoder{
+		seen: make(map[interface{}]int),
+		ids:  make(map[interface{}]int),
+	}
+}
+
+// count records how many times each node is reached from v.
+func (e *encoder) count(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			e.count(v.Elem())
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		e.seen[v.Interface()]++
+		if e.seen[v.Interface()] == 1 {
+			e.count(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			e.count(v.Index(i))
+		}
+	case reflect.Struct:
+		eachField(v, func(_ string, f reflect.Value) {
+			e.count(f)
+		})
+	}
+}
+
+func (e *encoder) value(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return e.value(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			return e.value(v.Elem())
+		}
+		key := v.Interface()
+		if id, ok := e.ids[key]; ok {
+			return object{{"ref", id}}
+		}
+		if e.seen[key] > 1 {
+			e.ids[key] = len(e.ids) + 1
+			return e.object(v.Elem(), e.ids[key])
+		}
+		return e.object(v.Elem(), 0)
+	case reflect.Struct:
+		return e.object(v, 0)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = e.value(v.Index(i))
+		}
+		return list
+	case reflect.Int:
+		if names, ok := enums[v.Type()]; ok {
+			return names.format(v.Int())
+		}
+		return v.Int()
+	}
+	return v.Interface()
+}
+
+// object encodes a struct of the ast package, numbering it with id if id is
+// not zero.
+func (e *encoder) object(v reflect.Value, id int) object {
+	o := object{{"kind", v.Type().Name()}}
+	if id != 0 {
+		o = append(o, member{"id", id})
+	}
+	if span := v.FieldByName("Span"); span.IsValid() && span.Type() == spanType {
+		s := span.Interface().(ast.Span)
+		if s.StartPos.Line > 0 {
+			o = append(o, member{"pos", position(s.StartPos)}, member{"end", position(s.EndPos)})
+		}
+	}
+	eachField(v, func(name string, f reflect.Value) {
+		o = append(o, member{name, e.value(f)})
+	})
+	return o
+}
+
+func position(p token.Position) object {
+	o := object{{"line", p.Line}, {"column", p.Column}, {"offset", p.Position}}
+	if p.File != "" {
+		o = append(o, member{"file", p.File})
+	}
+	return o
+}
+
+var (
+	spanType  = reflect.TypeOf(ast.Span{})
+	scopeType = reflect.TypeOf(ast.Scope{})
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// eachField calls f with the JSON name and value of each field of the
+// struct v that is encoded, flattening embedded structs that are not nodes
+// themselves.
+func eachField(v reflect.Value, f func(name string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.PkgPath != "" && !field.Anonymous:
+			// unexported
+		case field.Type == spanType, field.Type == scopeType:
+		case field.Name == "Parent" && field.Type == nodeType:
+		case field.Anonymous && field.Type.Kind() == reflect.Struct && !isNode(field.Type):
+			eachField(v.Field(i), f)
+		default:
+			f(lowerInitial(field.Name), v.Field(i))
+		}
+	}
+}
+
+// isNode reports whether values of the struct type t are syntax, having a
+// position of their own.
+func isNode(t reflect.Type) bool {
+	_, ok := t.FieldByName("Span")
+	return ok
+}
+
+func lowerInitial(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,91 @@
+package json
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+// enum lists the names of the values of an integer type of the ast
+// package, in order.
+type enum struct {
+	values []int64
+	names  []string
+	// flags is set for bitmasks, whose values can be combined.
+	flags bool
+}
+
+var typeNames = enum{
+	values: []int64{
+		int64(ast.String), int64(ast.Integer), int64(ast.Float),
+		int64(ast.Boolean), int64(ast.Null), int64(ast.Resource),
+		int64(ast.Array), int64(ast.Object), int64(ast.Function),
+	},
+	names: []string{
+		"string", "integer", "float",
+		"boolean", "null", "resource",
+		"array", "object", "function",
+	},
+	flags: true,
+}
+
+var enums = map[reflect.Type]enum{
+	reflect.TypeOf(ast.Type(0)):    typeNames,
+	reflect.TypeOf(ast.KeyType(0)): typeNames,
+	reflect.TypeOf(ast.Visibility(0)): {
+		values: []int64{int64(ast.Private), int64(ast.Protected), int64(ast.Public)},
+		names:  []string{"private", "protected", "public"},
+	},
+	reflect.TypeOf(ast.TypeForm(0)): {
+		values: []int64{int64(ast.NamedType), int64(ast.NullableType), int64(ast.UnionType), int64(ast.IntersectionType)},
+		names:  []string{"named", "nullable", "union", "intersection"},
+	},
+	reflect.TypeOf(ast.UseType(0)): {
+		values: []int64{int64(ast.UseClass), int64(ast.UseFunction), int64(ast.UseConst)},
+		names:  []string{"class", "function", "const"},
+	},
+}
+
+func (e enum) format(v int64) string {
+	if !e.flags {
+		for i, value := range e.values {
+			if value == v {
+				return e.names[i]
+			}
+		}
+		return fmt.Sprint(v)
+	}
+	var names []string
+	for i, value := range e.values {
+		if v&value != 0 {
+			names = append(names, e.names[i])
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+func (e enum) parse(s string) (int64, error) {
+	var v int64
+	if s == "" && e.flags {
+		return 0, nil
+	}
+	for _, name := range strings.Split(s, "|") {
+		found := false
+		for i := range e.names {
+			if e.names[i] == name {
+				v |= e.values[i]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("json: unknown name %q", name)
+		}
+		if !e.flags {
+			break
+		}
+	}
+	return v, nil
+}
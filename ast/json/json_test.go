@@ -0,0 +1,136 @@
+package json
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/parser"
+	"github.com/jxwr/php-parser/printer"
+)
+
+func sprint(t *testing.T, nodes []ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, nodes); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// roundTrip checks that src decodes to a tree that encodes and prints the
+// same as the one it was encoded from.
+func roundTrip(t *testing.T, name, src string) {
+	nodes, _ := parser.NewParserFile(name, src).Parse()
+	data, err := MarshalNodes(nodes)
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	back, err := UnmarshalNodes(data)
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	again, err := MarshalNodes(back)
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("%s: decoded tree encodes differently", name)
+	}
+	if got, want := sprint(t, back), sprint(t, nodes); got != want {
+		t.Errorf("%s: decoded tree prints\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		"$a = 1 + 2 * 'b';",
+		"$a ?: $b;",
+		"foreach ($a as $k => &$v) { yield $k => $v; }",
+		"function &f(?int $a = 1, string|Foo ...$b): static { return $a; }",
+		"abstract class A extends B implements C { use T { T::f insteadof U; g as private h; } const X = 1; private static ?int $p; abstract public function m(); }",
+		"try { f(...$a, b: 1); } catch (A|B) { } finally { }",
+		"namespace N; use A\\B as C, function f;",
+		"$a = ;", // a BadStmt or BadExpr
+	}
+	for _, src := range tests {
+		roundTrip(t, src, "<?php "+src)
+	}
+	files, err := filepath.Glob("../../test/php-files/*.php")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no test files: %v", err)
+	}
+	for _, name := range files {
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roundTrip(t, name, string(src))
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	nodes, _ := parser.NewParserFile("a.php", "<?php $a;").Parse()
+	data, err := Marshal(nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"ExpressionStmt",` +
+		`"pos":{"line":1,"column":7,"offset":6,"file":"a.php"},` +
+		`"end":{"line":1,"column":10,"offset":9,"file":"a.php"},` +
+		`"expression":{"kind":"Variable",` +
+		`"pos":{"line":1,"column":7,"offset":6,"file":"a.php"},` +
+		`"end":{"line":1,"column":9,"offset":8,"file":"a.php"},` +
+		`"name":{"kind":"Identifier",` +
+		`"pos":{"line":1,"column":8,"offset":7,"file":"a.php"},` +
+		`"end":{"line":1,"column":9,"offset":8,"file":"a.php"},` +
+		`"value":"a"},` +
+		`"type":"string|integer|float|boolean|null|resource|array|object"}}`
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestSharedNode(t *testing.T) {
+	nodes, _ := parser.NewParser("<?php $a ?: $b;").Parse()
+	data, err := MarshalNodes(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"true":{"ref":1}`) {
+		t.Errorf("repeated condition is not a reference: %s", data)
+	}
+	back, err := UnmarshalNodes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := back[0].(*ast.ExpressionStmt).Expression.(*ast.TernaryExpression)
+	if e.Condition != e.True {
+		t.Error("decoded condition and true branch are different nodes")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		data, want string
+	}{
+		{`{"kind":"Nonsense"}`, `json: unknown node kind "Nonsense"`},
+		{`{"ref":7}`, "json: reference to unknown node 7"},
+		{`"a"`, "json: expected an object for a node"},
+		{`{"kind":"ExpressionStmt","pos":1}`, "json: expected an object for a position"},
+		{`{"kind":"Identifier","value":1}`, "json: expected a string for string"},
+		{`{"kind":"ReturnStmt","expression":{"kind":"Block"}}`, "json: cannot use Block as ast.Expression"},
+		{`{`, "unexpected EOF"},
+	}
+	for _, test := range tests {
+		_, err := Unmarshal([]byte(test.data))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%s: got error %v, want %s", test.data, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package ast
+
+// Cursor describes a node encountered during Rewrite, and allows it to be
+// modified in place.
+type Cursor struct {
+	parent Node
+	node   Node
+	set    func(Node)
+
+	// block and index locate the node when it is one of the statements of
+	// a block, the only place where it can be deleted or have statements
+	// inserted around it. After a Delete, index is where the node was. step
+	// is the number of statements from index to the next one to visit: one,
+	// less one for a deleted node, plus one for each statement inserted
+	// after it.
+	block   *Block
+	index   int
+	step    int
+	deleted bool
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the parent of the current node, or nil at the root.
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Index returns the index of the current node within the statements of its
+// parent block, or -1 if the parent is not a block.
+func (c *Cursor) Index() int {
+	if c.block == nil {
+		return -1
+	}
+	return c.index
+}
+
+// Replace replaces the current node with n. It panics if n cannot be stored
+// where the current node was, such as a statement in place of an
+// expression, or nil in place of a parameter, a member of a class or a
+// catch clause, which are not optional. The children of n are visited
+// next.
+func (c *Cursor) Replace(n Node) {
+	if c.deleted {
+		panic("ast: Replace called on deleted node")
+	}
+	c.set(n)
+	c.node = n
+}
+
+// Delete removes the current statement from its block. It panics if the
+// current node is not a statement of a block.
+func (c *Cursor) Delete() {
+	c.inBlock("Delete")
+	s := c.block.Statements
+	c.block.Statements = append(s[:c.index], s[c.index+1:]...)
+	c.step--
+	c.deleted = true
+}
+
+// InsertBefore inserts n before the current statement in its block, or
+// where it was if it has been deleted. The inserted statement is not
+// visited. It panics if the current node is not a statement of a block.
+func (c *Cursor) InsertBefore(n Statement) {
+	c.inBlock("InsertBefore")
+	c.insert(c.index, n)
+	c.index++
+}
+
+// InsertAfter inserts n after the current statement in its block. The
+// inserted statement is not visited. It panics if the current node is not a
+// statement of a block.
+func (c *Cursor) InsertAfter(n Statement) {
+	c.inBlock("InsertAfter")
+	c.insert(c.index+c.step, n)
+	c.step++
+}
+
+func (c *Cursor) inBlock(method string) {
+	if c.block == nil {
+		panic("ast: " + method + " called on a node that is not a statement of a block")
+	}
+}
+
+func (c *Cursor) insert(i int, n Statement) {
+	s := append(c.block.Statements, nil)
+	copy(s[i+1:], s[i:])
+	s[i] = n
+	c.block.Statements = s
+}
+
+// Rewrite traverses the tree rooted at n in depth-first order, calling f
+// with a cursor for each node before visiting its children. If f returns
+// false, the children of the node are skipped. Rewrite returns the root,
+// which differs from n if f replaced it.
+func Rewrite(n Node, f func(*Cursor) bool) Node {
+	r := &rewriter{f: f}
+	r.visit(nil, n, func(x Node) { n = x })
+	return n
+}
+
+type rewriter struct {
+	f func(*Cursor) bool
+}
+
+func (r *rewriter) visit(parent, n Node, set func(Node)) {
+	r.apply(&Cursor{parent: parent, node: n, set: set})
+}
+
+func (r *rewriter) apply(c *Cursor) {
+	if !r.f(c) || c.deleted || c.node == nil {
+		return
+	}
+	r.children(c.node)
+}
+
+func (r *rewriter) statements(b *Block) {
+	for i := 0; i < len(b.Statements); {
+		c := &Cursor{parent: b, node: b.Statements[i], block: b, index: i, step: 1}
+		c.set = func(n Node) { b.Statements[c.index] = toStatement(n) }
+		r.apply(c)
+		i = c.index + c.step
+	}
+}
+
+func toExpression(n Node) Expression {
+	if n == nil {
+		return nil
+	}
+	return n.(Expression)
+}
+
+func toStatement(n Node) Statement {
+	if n == nil {
+		return nil
+	}
+	return n.(Statement)
+}
+
+// required returns n, which replaces a node that is not optional, panicking
+// if it is nil.
+func required(n Node) Node {
+	if n == nil {
+		panic("ast: Replace called with nil on a node that is not optional")
+	}
+	return n
+}
+
+func (r *rewriter) expr(parent Node, e *Expression) {
+	if *e != nil {
+		r.visit(parent, *e, func(n Node) { *e = toExpression(n) })
+	}
+}
+
+func (r *rewriter) exprs(parent Node, list []Expression) {
+	for i := range list {
+		r.expr(parent, &list[i])
+	}
+}
+
+func (r *rewriter) stmt(parent Node, s *Statement) {
+	if *s != nil {
+		r.visit(parent, *s, func(n Node) { *s = toStatement(n) })
+	}
+}
+
+func (r *rewriter) block(parent Node, b **Block) {
+	if *b != nil {
+		r.visit(parent, *b, func(n Node) {
+			if n == nil {
+				*b = nil
+				return
+			}
+			*b = n.(*Block)
+		})
+	}
+}
+
+func (r *rewriter) variable(parent Node, v **Variable) {
+	if *v != nil {
+		r.visit(parent, *v, func(n Node) {
+			if n == nil {
+				*v = nil
+				return
+			}
+			*v = n.(*Variable)
+		})
+	}
+}
+
+func (r *rewriter) args(parent Node, list []*FunctionArgument) {
+	for i := range list {
+		i := i
+		r.visit(parent, list[i], func(n Node) { list[i] = required(n).(*FunctionArgument) })
+	}
+}
+
+func (r *rewriter) typeExpr(parent Node, t **TypeExpr) {
+	if *t != nil {
+		r.visit(parent, *t, func(n Node) {
+			if n == nil {
+				*t = nil
+				return
+			}
+			*t = n.(*TypeExpr)
+		})
+	}
+}
+
+func (r *rewriter) function(parent Node, f *FunctionStmt) {
+	if f.FunctionDefinition != nil {
+		r.visit(parent, f.FunctionDefinition, func(n Node) {
+			if n == nil {
+				f.FunctionDefinition = nil
+				return
+			}
+			f.FunctionDefinition = n.(*FunctionDefinition)
+		})
+	}
+	r.block(parent, &f.Body)
+}
+
+func (r *rewriter) constants(parent Node, list []*Constant) {
+	for i := range list {
+		i := i
+		r.visit(parent, list[i], func(n Node) { list[i] = required(n).(*Constant) })
+	}
+}
+
+func (r *rewriter) properties(parent Node, list []*Property) {
+	for i := range list {
+		i := i
+		r.visit(parent, list[i], func(n Node) { list[i] = required(n).(*Property) })
+	}
+}
+
+func (r *rewriter) methods(parent Node, list []*Method) {
+	for i := range list {
+		i := i
+		r.visit(parent, list[i], func(n Node) { list[i] = required(n).(*Method) })
+	}
+}
+
+func (r *rewriter) traitUses(parent Node, list []*TraitUse) {
+	for i := range list {
+		i := i
+		r.visit(parent, list[i], func(n Node) { list[i] = required(n).(*TraitUse) })
+	}
+}
+
+// children visits the children of n in the same order as WalkChildren.
+func (r *rewriter) children(n Node) {
+	switch n := n.(type) {
+	case *Variable:
+		r.expr(n, &n.Name)
+	case *BinaryExpression:
+		r.expr(n, &n.Antecedent)
+		r.expr(n, &n.Subsequent)
+	case *TernaryExpression:
+		r.expr(n, &n.Condition)
+		// a ternary without a middle operand reuses its condition
+		if n.True != n.Condition {
+			r.expr(n, &n.True)
+		}
+		r.expr(n, &n.False)
+	case *UnaryExpression:
+		r.expr(n, &n.Operand)
+	case *NewExpression:
+		r.expr(n, &n.Class)
+		r.exprs(n, n.Arguments)
+	case *PropertyExpression:
+		r.expr(n, &n.Receiver)
+		r.expr(n, &n.Name)
+	case *ClassExpression:
+		r.expr(n, &n.Receiver)
+		r.expr(n, &n.Expression)
+	case *AssignmentExpression:
+		if n.Assignee != nil {
+			r.visit(n, n.Assignee, func(x Node) { n.Assignee = x })
+		}
+		r.expr(n, &n.Value)
+	case *FunctionCallExpression:
+		r.expr(n, &n.FunctionName)
+		r.exprs(n, n.Arguments)
+	case *MethodCallExpression:
+		r.expr(n, &n.Receiver)
+		if n.FunctionCallExpression != nil {
+			r.expr(n, &n.FunctionName)
+			r.exprs(n, n.Arguments)
+		}
+	case *CallArgument:
+		r.expr(n, &n.Value)
+	case *ArrayExpression:
+		for i := range n.Pairs {
+			r.expr(n, &n.Pairs[i].Key)
+			r.expr(n, &n.Pairs[i].Value)
+		}
+	case *ArrayLookupExpression:
+		r.expr(n, &n.Array)
+		r.expr(n, &n.Index)
+	case *ArrayAppendExpression:
+		r.expr(n, &n.Array)
+	case *Include:
+		r.exprs(n, n.Expressions)
+	case *AnonymousFunction:
+		r.args(n, n.Arguments)
+		r.args(n, n.ClosureVariables)
+		r.typeExpr(n, &n.ReturnType)
+		r.block(n, &n.Body)
+	case *YieldExpression:
+		r.expr(n, &n.Key)
+		r.expr(n, &n.Value)
+	case *YieldFromExpression:
+		r.expr(n, &n.Source)
+
+	case *GlobalDeclaration:
+		for i := range n.Identifiers {
+			r.variable(n, &n.Identifiers[i])
+		}
+	case *ExpressionStmt:
+		r.expr(n, &n.Expression)
+	case *EchoStmt:
+		r.exprs(n, n.Expressions)
+	case *ReturnStmt:
+		r.expr(n, &n.Expression)
+	case *BreakStmt:
+		r.expr(n, &n.Expression)
+	case *ContinueStmt:
+		r.expr(n, &n.Expression)
+	case *ThrowStmt:
+		r.expr(n, &n.Expression)
+	case *IncludeStmt:
+		r.exprs(n, n.Expressions)
+	case *ExitStmt:
+		r.expr(n, &n.Expression)
+	case *FunctionCallStmt:
+		r.expr(n, &n.FunctionName)
+		r.exprs(n, n.Arguments)
+	case *FunctionStmt:
+		r.function(n, n)
+	case *FunctionDefinition:
+		r.args(n, n.Arguments)
+		r.typeExpr(n, &n.ReturnType)
+	case *FunctionArgument:
+		r.typeExpr(n, &n.TypeHint)
+		r.variable(n, &n.Variable)
+		r.expr(n, &n.Default)
+	case *TypeExpr:
+		for i := range n.Types {
+			r.typeExpr(n, &n.Types[i])
+		}
+	case *Interface:
+		r.constants(n, n.Constants)
+		r.methods(n, n.Methods)
+	case *DeclareBlock:
+		r.block(n, &n.Statements)
+	case *Class:
+		r.traitUses(n, n.TraitUses)
+		r.constants(n, n.Constants)
+		r.properties(n, n.Properties)
+		r.methods(n, n.Methods)
+	case *Trait:
+		r.traitUses(n, n.TraitUses)
+		r.constants(n, n.Constants)
+		r.properties(n, n.Properties)
+		r.methods(n, n.Methods)
+	case *Constant:
+		r.variable(n, &n.Variable)
+		if e, ok := n.Value.(Expression); ok {
+			r.expr(n, &e)
+			n.Value = e
+		}
+	case *Property:
+		r.typeExpr(n, &n.TypeHint)
+		r.expr(n, &n.Initialization)
+	case *Method:
+		if n.FunctionStmt != nil {
+			r.function(n, n.FunctionStmt)
+		}
+	case *Block:
+		r.statements(n)
+	case *IfStmt:
+		r.expr(n, &n.Condition)
+		r.stmt(n, &n.TrueBranch)
+		r.stmt(n, &n.FalseBranch)
+	case *SwitchStmt:
+		r.expr(n, &n.Expression)
+		for _, c := range n.Cases {
+			r.expr(n, &c.Expression)
+			r.visit(n, &c.Block, func(x Node) { c.Block = *required(x).(*Block) })
+		}
+		r.block(n, &n.DefaultCase)
+	case *ForStmt:
+		r.exprs(n, n.Initialization)
+		r.exprs(n, n.Termination)
+		r.exprs(n, n.Iteration)
+		r.stmt(n, &n.LoopBlock)
+	case *WhileStmt:
+		r.expr(n, &n.Termination)
+		r.stmt(n, &n.LoopBlock)
+	case *DoWhileStmt:
+		r.stmt(n, &n.LoopBlock)
+		r.expr(n, &n.Termination)
+	case *TryStmt:
+		r.block(n, &n.TryBlock)
+		for i := range n.CatchStmts {
+			i := i
+			r.visit(n, n.CatchStmts[i], func(x Node) { n.CatchStmts[i] = required(x).(*CatchStmt) })
+		}
+		r.block(n, &n.FinallyBlock)
+	case *CatchStmt:
+		r.variable(n, &n.CatchVar)
+		r.block(n, &n.CatchBlock)
+	case *ForeachStmt:
+		r.expr(n, &n.Source)
+		r.variable(n, &n.Key)
+		r.variable(n, &n.Value)
+		r.stmt(n, &n.LoopBlock)
+	case *ListStatement:
+		for i := range n.Assignees {
+			i := i
+			if n.Assignees[i] != nil {
+				r.visit(n, n.Assignees[i], func(x Node) { n.Assignees[i] = x })
+			}
+		}
+		r.expr(n, &n.Value)
+	case *StaticVariableDeclaration:
+		r.exprs(n, n.Declarations)
+	case *NamespaceStmt:
+		r.block(n, &n.Block)
+	}
+}
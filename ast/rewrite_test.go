@@ -0,0 +1,258 @@
+package ast_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/printer"
+)
+
+// render prints nodes without the opening tag.
+func render(t *testing.T, nodes []ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, nodes); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(buf.String(), "<?php\n\n")
+}
+
+func variable(name string) *ast.Variable {
+	return &ast.Variable{Name: &ast.Identifier{Value: name}}
+}
+
+func call(name string) *ast.ExpressionStmt {
+	return &ast.ExpressionStmt{Expression: &ast.FunctionCallExpression{FunctionName: &ast.Identifier{Value: name}}}
+}
+
+// isVariable reports whether n is the variable named name.
+func isVariable(n ast.Node, name string) bool {
+	v, ok := n.(*ast.Variable)
+	if !ok {
+		return false
+	}
+	id, ok := v.Name.(*ast.Identifier)
+	return ok && id.Value == name
+}
+
+// isCall reports whether n is a statement calling the function named name.
+func isCall(n ast.Node, name string) bool {
+	s, ok := n.(*ast.ExpressionStmt)
+	if !ok {
+		return false
+	}
+	c, ok := s.Expression.(*ast.FunctionCallExpression)
+	if !ok {
+		return false
+	}
+	id, ok := c.FunctionName.(*ast.Identifier)
+	return ok && id.Value == name
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		f    func(*ast.Cursor) bool
+		want string
+	}{
+		{
+			"rename variable",
+			"function f($a) { return $a + $b; }",
+			func(c *ast.Cursor) bool {
+				if isVariable(c.Node(), "a") {
+					c.Replace(variable("x"))
+				}
+				return true
+			},
+			"function f($x)\n{\n    return $x + $b;\n}\n",
+		},
+		{
+			"rename property and constant",
+			"class A { const C = 1; public $p = 2; }",
+			func(c *ast.Cursor) bool {
+				switch n := c.Node().(type) {
+				case *ast.Property:
+					c.Replace(&ast.Property{Visibility: ast.Private, Name: "$q", Initialization: n.Initialization})
+				case *ast.Constant:
+					c.Replace(&ast.Constant{Variable: variable("D"), Value: n.Value})
+				}
+				return true
+			},
+			"class A\n{\n    const D = 1;\n\n    private $q = 2;\n}\n",
+		},
+		{
+			"delete statement",
+			"function f() { a(); b(); if ($x) { b(); c(); } }",
+			func(c *ast.Cursor) bool {
+				if isCall(c.Node(), "b") {
+					c.Delete()
+				}
+				return true
+			},
+			"function f()\n{\n    a();\n    if ($x) {\n        c();\n    }\n}\n",
+		},
+		{
+			"insert statements",
+			"function f() { a(); b(); }",
+			func(c *ast.Cursor) bool {
+				if isCall(c.Node(), "b") {
+					c.InsertBefore(call("before"))
+					c.InsertAfter(call("after1"))
+					c.InsertAfter(call("after2"))
+				}
+				return true
+			},
+			"function f()\n{\n    a();\n    before();\n    b();\n    after1();\n    after2();\n}\n",
+		},
+		{
+			"insert where a statement was deleted",
+			"function f() { a(); b(); c(); }",
+			func(c *ast.Cursor) bool {
+				if isCall(c.Node(), "b") {
+					c.Delete()
+					c.InsertBefore(call("x"))
+					c.InsertAfter(call("y"))
+				}
+				return true
+			},
+			"function f()\n{\n    a();\n    x();\n    y();\n    c();\n}\n",
+		},
+		{
+			"replace with nil",
+			"if ($a) { b(); } else { c(); } function f(): int { return $a; }",
+			func(c *ast.Cursor) bool {
+				switch n := c.Parent().(type) {
+				case *ast.IfStmt:
+					if c.Node() == n.FalseBranch {
+						c.Replace(nil)
+					}
+				case *ast.ReturnStmt:
+					c.Replace(nil)
+				}
+				return true
+			},
+			"if ($a) {\n    b();\n}\n\nfunction f(): int\n{\n    return;\n}\n",
+		},
+		{
+			"inserted statements are not visited",
+			"function f() { a(); }",
+			func(c *ast.Cursor) bool {
+				if isCall(c.Node(), "a") {
+					c.InsertAfter(call("a"))
+				}
+				return true
+			},
+			"function f()\n{\n    a();\n    a();\n}\n",
+		},
+		{
+			"skip children",
+			"f($a); function g() { $a; }",
+			func(c *ast.Cursor) bool {
+				if _, ok := c.Node().(*ast.FunctionStmt); ok {
+					return false
+				}
+				if isVariable(c.Node(), "a") {
+					c.Replace(variable("b"))
+				}
+				return true
+			},
+			"f($b);\n\nfunction g()\n{\n    $a;\n}\n",
+		},
+		{
+			"replaced node's children are visited",
+			"$a;",
+			func(c *ast.Cursor) bool {
+				if isVariable(c.Node(), "a") {
+					c.Replace(&ast.BinaryExpression{Antecedent: variable("b"), Subsequent: variable("c"), Operator: "+"})
+				}
+				if isVariable(c.Node(), "c") {
+					c.Replace(variable("d"))
+				}
+				return true
+			},
+			"$b + $d;\n",
+		},
+	}
+	for _, test := range tests {
+		nodes := parse(t, test.src)
+		for i := range nodes {
+			nodes[i] = ast.Rewrite(nodes[i], test.f)
+		}
+		if got := render(t, nodes); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRewriteRoot(t *testing.T) {
+	n := parse(t, "$a;")[0].(*ast.ExpressionStmt)
+	got := ast.Rewrite(n.Expression, func(c *ast.Cursor) bool {
+		if c.Parent() == nil {
+			c.Replace(variable("b"))
+		}
+		return true
+	})
+	if !isVariable(got, "b") {
+		t.Errorf("got %#v, want $b", got)
+	}
+}
+
+func TestCursorIndex(t *testing.T) {
+	var indexes []int
+	ast.Rewrite(parse(t, "if ($a) { a(); b(); }")[0], func(c *ast.Cursor) bool {
+		if _, ok := c.Node().(*ast.ExpressionStmt); ok {
+			indexes = append(indexes, c.Index())
+		}
+		if isVariable(c.Node(), "a") && c.Index() != -1 {
+			t.Errorf("index of a condition is %d, want -1", c.Index())
+		}
+		return true
+	})
+	if len(indexes) != 2 || indexes[0] != 0 || indexes[1] != 1 {
+		t.Errorf("statement indexes are %v, want [0 1]", indexes)
+	}
+}
+
+func TestDeleteOutsideBlock(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("deleting an expression did not panic")
+		}
+	}()
+	ast.Rewrite(parse(t, "$a;")[0], func(c *ast.Cursor) bool {
+		if isVariable(c.Node(), "a") {
+			c.Delete()
+		}
+		return true
+	})
+}
+
+func TestReplaceRequiredWithNil(t *testing.T) {
+	tests := []struct {
+		src  string
+		node func(ast.Node) bool
+	}{
+		{"function f($a) {}", func(n ast.Node) bool { _, ok := n.(*ast.FunctionArgument); return ok }},
+		{"class A { public function f() {} }", func(n ast.Node) bool { _, ok := n.(*ast.Method); return ok }},
+		{"class A { use T; }", func(n ast.Node) bool { _, ok := n.(*ast.TraitUse); return ok }},
+		{"try {} catch (E $e) {}", func(n ast.Node) bool { _, ok := n.(*ast.CatchStmt); return ok }},
+		{"switch ($a) { case 1: b(); }", func(n ast.Node) bool { _, ok := n.(*ast.Block); return ok }},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r, _ := recover().(string); !strings.HasPrefix(r, "ast: Replace called with nil") {
+					t.Errorf("%q: got panic %q, want one from Replace", test.src, r)
+				}
+			}()
+			ast.Rewrite(parse(t, test.src)[0], func(c *ast.Cursor) bool {
+				if test.node(c.Node()) {
+					c.Replace(nil)
+				}
+				return true
+			})
+		}()
+	}
+}
@@ -0,0 +1,22 @@
+package ast
+
+import "github.com/jxwr/php-parser/token"
+
+// Span records the range of source a node was parsed from. It is embedded in
+// every node so that each one satisfies the Pos and End methods of Node.
+type Span struct {
+	StartPos token.Position
+	EndPos   token.Position
+}
+
+// Pos returns the position of the first character belonging to the node.
+func (s *Span) Pos() token.Position { return s.StartPos }
+
+// End returns the position immediately after the node.
+func (s *Span) End() token.Position { return s.EndPos }
+
+// SetSpan sets the range of source the node was parsed from.
+func (s *Span) SetSpan(begin, end token.Position) {
+	s.StartPos = begin
+	s.EndPos = end
+}
@@ -37,9 +37,10 @@ func (t Type) Contains(typ Type) bool {
 	return t&typ != 0
 }
 
+// List returns the types t is made up of, in the order they are declared.
 func (t Type) List() []Type {
 	list := make([]Type, 0)
-	for typ := range typeMap {
+	for typ := String; typ <= Function; typ <<= 1 {
 		if t.Contains(typ) {
 			list = append(list, typ)
 		}
@@ -1,6 +1,7 @@
 package ast
 
 type Visitor interface {
+	VisitBadExpr(n *BadExpr)
 	VisitIdentifier(n *Identifier)
 	VisitVariable(n *Variable)
 	VisitBinaryExpression(n *BinaryExpression)
@@ -19,6 +20,11 @@ type Visitor interface {
 	VisitLiteral(n *Literal)
 	VisitInclude(n *Include)
 	VisitAnonymousFunction(n *AnonymousFunction)
+	VisitYieldExpression(n *YieldExpression)
+	VisitYieldFromExpression(n *YieldFromExpression)
+	VisitMethodCallExpression(n *MethodCallExpression)
+	VisitCallArgument(n *CallArgument)
+	VisitBadStmt(n *BadStmt)
 	VisitGlobalDeclaration(n *GlobalDeclaration)
 	VisitExpressionStmt(n *ExpressionStmt)
 	VisitEmptyStatement(n *EmptyStatement)
@@ -32,9 +38,15 @@ type Visitor interface {
 	VisitFunctionCallStmt(n *FunctionCallStmt)
 	VisitFunctionStmt(n *FunctionStmt)
 	VisitFunctionDefinition(n *FunctionDefinition)
+	VisitFunctionArgument(n *FunctionArgument)
+	VisitTypeExpr(n *TypeExpr)
 	VisitInterface(n *Interface)
 	VisitDeclareBlock(n *DeclareBlock)
 	VisitClass(n *Class)
+	VisitTrait(n *Trait)
+	VisitTraitUse(n *TraitUse)
+	VisitConstant(n *Constant)
+	VisitProperty(n *Property)
 	VisitMethod(n *Method)
 	VisitBlock(n *Block)
 	VisitIfStmt(n *IfStmt)
@@ -47,4 +59,84 @@ type Visitor interface {
 	VisitForeachStmt(n *ForeachStmt)
 	VisitListStatement(n *ListStatement)
 	VisitStaticVariableDeclaration(n *StaticVariableDeclaration)
+	VisitNamespaceStmt(n *NamespaceStmt)
+	VisitUseStmt(n *UseStmt)
 }
+
+// BaseVisitor implements every Visitor method by walking the children of the
+// visited node. Embed it in a visitor to override only the methods of
+// interest, and start the traversal with Walk so that children are
+// dispatched to the embedding visitor rather than to BaseVisitor itself.
+type BaseVisitor struct {
+	visitor Visitor
+}
+
+func (b *BaseVisitor) setVisitor(v Visitor) { b.visitor = v }
+
+func (b *BaseVisitor) walk(n Node) {
+	if b.visitor == nil {
+		b.visitor = b
+	}
+	WalkChildren(b.visitor, n)
+}
+
+func (b *BaseVisitor) VisitBadExpr(n *BadExpr)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitIdentifier(n *Identifier)                               { b.walk(n) }
+func (b *BaseVisitor) VisitVariable(n *Variable)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitBinaryExpression(n *BinaryExpression)                   { b.walk(n) }
+func (b *BaseVisitor) VisitTernaryExpression(n *TernaryExpression)                 { b.walk(n) }
+func (b *BaseVisitor) VisitUnaryExpression(n *UnaryExpression)                     { b.walk(n) }
+func (b *BaseVisitor) VisitNewExpression(n *NewExpression)                         { b.walk(n) }
+func (b *BaseVisitor) VisitPropertyExpression(n *PropertyExpression)               { b.walk(n) }
+func (b *BaseVisitor) VisitClassExpression(n *ClassExpression)                     { b.walk(n) }
+func (b *BaseVisitor) VisitAssignmentExpression(n *AssignmentExpression)           { b.walk(n) }
+func (b *BaseVisitor) VisitFunctionCallExpression(n *FunctionCallExpression)       { b.walk(n) }
+func (b *BaseVisitor) VisitConstantExpression(n *ConstantExpression)               { b.walk(n) }
+func (b *BaseVisitor) VisitArrayExpression(n *ArrayExpression)                     { b.walk(n) }
+func (b *BaseVisitor) VisitArrayLookupExpression(n *ArrayLookupExpression)         { b.walk(n) }
+func (b *BaseVisitor) VisitArrayAppendExpression(n *ArrayAppendExpression)         { b.walk(n) }
+func (b *BaseVisitor) VisitShellCommand(n *ShellCommand)                           { b.walk(n) }
+func (b *BaseVisitor) VisitLiteral(n *Literal)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitInclude(n *Include)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitAnonymousFunction(n *AnonymousFunction)                 { b.walk(n) }
+func (b *BaseVisitor) VisitYieldExpression(n *YieldExpression)                     { b.walk(n) }
+func (b *BaseVisitor) VisitYieldFromExpression(n *YieldFromExpression)             { b.walk(n) }
+func (b *BaseVisitor) VisitMethodCallExpression(n *MethodCallExpression)           { b.walk(n) }
+func (b *BaseVisitor) VisitCallArgument(n *CallArgument)                           { b.walk(n) }
+func (b *BaseVisitor) VisitBadStmt(n *BadStmt)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitGlobalDeclaration(n *GlobalDeclaration)                 { b.walk(n) }
+func (b *BaseVisitor) VisitExpressionStmt(n *ExpressionStmt)                       { b.walk(n) }
+func (b *BaseVisitor) VisitEmptyStatement(n *EmptyStatement)                       { b.walk(n) }
+func (b *BaseVisitor) VisitEchoStmt(n *EchoStmt)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitReturnStmt(n *ReturnStmt)                               { b.walk(n) }
+func (b *BaseVisitor) VisitBreakStmt(n *BreakStmt)                                 { b.walk(n) }
+func (b *BaseVisitor) VisitContinueStmt(n *ContinueStmt)                           { b.walk(n) }
+func (b *BaseVisitor) VisitThrowStmt(n *ThrowStmt)                                 { b.walk(n) }
+func (b *BaseVisitor) VisitIncludeStmt(n *IncludeStmt)                             { b.walk(n) }
+func (b *BaseVisitor) VisitExitStmt(n *ExitStmt)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitFunctionCallStmt(n *FunctionCallStmt)                   { b.walk(n) }
+func (b *BaseVisitor) VisitFunctionStmt(n *FunctionStmt)                           { b.walk(n) }
+func (b *BaseVisitor) VisitFunctionDefinition(n *FunctionDefinition)               { b.walk(n) }
+func (b *BaseVisitor) VisitFunctionArgument(n *FunctionArgument)                   { b.walk(n) }
+func (b *BaseVisitor) VisitTypeExpr(n *TypeExpr)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitInterface(n *Interface)                                 { b.walk(n) }
+func (b *BaseVisitor) VisitDeclareBlock(n *DeclareBlock)                           { b.walk(n) }
+func (b *BaseVisitor) VisitClass(n *Class)                                         { b.walk(n) }
+func (b *BaseVisitor) VisitTrait(n *Trait)                                         { b.walk(n) }
+func (b *BaseVisitor) VisitTraitUse(n *TraitUse)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitConstant(n *Constant)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitProperty(n *Property)                                   { b.walk(n) }
+func (b *BaseVisitor) VisitMethod(n *Method)                                       { b.walk(n) }
+func (b *BaseVisitor) VisitBlock(n *Block)                                         { b.walk(n) }
+func (b *BaseVisitor) VisitIfStmt(n *IfStmt)                                       { b.walk(n) }
+func (b *BaseVisitor) VisitSwitchStmt(n *SwitchStmt)                               { b.walk(n) }
+func (b *BaseVisitor) VisitForStmt(n *ForStmt)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitWhileStmt(n *WhileStmt)                                 { b.walk(n) }
+func (b *BaseVisitor) VisitDoWhileStmt(n *DoWhileStmt)                             { b.walk(n) }
+func (b *BaseVisitor) VisitTryStmt(n *TryStmt)                                     { b.walk(n) }
+func (b *BaseVisitor) VisitCatchStmt(n *CatchStmt)                                 { b.walk(n) }
+func (b *BaseVisitor) VisitForeachStmt(n *ForeachStmt)                             { b.walk(n) }
+func (b *BaseVisitor) VisitListStatement(n *ListStatement)                         { b.walk(n) }
+func (b *BaseVisitor) VisitStaticVariableDeclaration(n *StaticVariableDeclaration) { b.walk(n) }
+func (b *BaseVisitor) VisitNamespaceStmt(n *NamespaceStmt)                         { b.walk(n) }
+func (b *BaseVisitor) VisitUseStmt(n *UseStmt)                                     { b.walk(n) }
@@ -0,0 +1,280 @@
+package ast
+
+// Walk traverses the tree rooted at n with v. Visitors embedding BaseVisitor
+// should always be started with Walk, so that the children of nodes they do
+// not override are dispatched back to them.
+func Walk(v Visitor, n Node) {
+	if b, ok := v.(interface {
+		setVisitor(Visitor)
+	}); ok {
+		b.setVisitor(v)
+	}
+	n.Accept(v)
+}
+
+// WalkChildren visits each direct child of n with v, in source order. It is
+// meant to be called from Visitor methods that want to continue the
+// traversal below the node they handle.
+func WalkChildren(v Visitor, n Node) {
+	eachChild(n, func(c Node) {
+		c.Accept(v)
+	})
+}
+
+// Inspect traverses the tree rooted at n in depth-first order. It starts by
+// calling f(n); if f returns true, Inspect is called recursively for each of
+// the children of n, followed by a call of f(nil).
+func Inspect(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+	eachChild(n, func(c Node) {
+		Inspect(c, f)
+	})
+	f(nil)
+}
+
+// eachChild calls f with each direct child of n, in source order, skipping
+// children that are missing.
+func eachChild(n Node, f func(Node)) {
+	expr := func(e Expression) {
+		if e != nil {
+			f(e)
+		}
+	}
+	exprs := func(list []Expression) {
+		for _, e := range list {
+			expr(e)
+		}
+	}
+	stmt := func(s Statement) {
+		if s != nil {
+			f(s)
+		}
+	}
+	block := func(b *Block) {
+		if b != nil {
+			f(b)
+		}
+	}
+	variable := func(v *Variable) {
+		if v != nil {
+			f(v)
+		}
+	}
+	args := func(list []*FunctionArgument) {
+		for _, n := range list {
+			f(n)
+		}
+	}
+	typeExpr := func(t *TypeExpr) {
+		if t != nil {
+			f(t)
+		}
+	}
+	function := func(def *FunctionDefinition, body *Block) {
+		if def != nil {
+			f(def)
+		}
+		block(body)
+	}
+	constants := func(list []*Constant) {
+		for _, n := range list {
+			f(n)
+		}
+	}
+	properties := func(list []*Property) {
+		for _, n := range list {
+			f(n)
+		}
+	}
+	methods := func(list []*Method) {
+		for _, n := range list {
+			f(n)
+		}
+	}
+	traitUses := func(list []*TraitUse) {
+		for _, u := range list {
+			f(u)
+		}
+	}
+
+	switch n := n.(type) {
+	case *Variable:
+		expr(n.Name)
+	case *BinaryExpression:
+		expr(n.Antecedent)
+		expr(n.Subsequent)
+	case *TernaryExpression:
+		expr(n.Condition)
+		// a ternary without a middle operand reuses its condition
+		if n.True != n.Condition {
+			expr(n.True)
+		}
+		expr(n.False)
+	case *UnaryExpression:
+		expr(n.Operand)
+	case *NewExpression:
+		expr(n.Class)
+		exprs(n.Arguments)
+	case *PropertyExpression:
+		expr(n.Receiver)
+		expr(n.Name)
+	case *ClassExpression:
+		expr(n.Receiver)
+		expr(n.Expression)
+	case *AssignmentExpression:
+		if n.Assignee != nil {
+			f(n.Assignee)
+		}
+		expr(n.Value)
+	case *FunctionCallExpression:
+		expr(n.FunctionName)
+		exprs(n.Arguments)
+	case *MethodCallExpression:
+		expr(n.Receiver)
+		if n.FunctionCallExpression != nil {
+			expr(n.FunctionName)
+			exprs(n.Arguments)
+		}
+	case *CallArgument:
+		expr(n.Value)
+	case *ArrayExpression:
+		for _, pair := range n.Pairs {
+			expr(pair.Key)
+			expr(pair.Value)
+		}
+	case *ArrayLookupExpression:
+		expr(n.Array)
+		expr(n.Index)
+	case *ArrayAppendExpression:
+		expr(n.Array)
+	case *Include:
+		exprs(n.Expressions)
+	case *AnonymousFunction:
+		args(n.Arguments)
+		args(n.ClosureVariables)
+		typeExpr(n.ReturnType)
+		block(n.Body)
+	case *YieldExpression:
+		expr(n.Key)
+		expr(n.Value)
+	case *YieldFromExpression:
+		expr(n.Source)
+
+	case *GlobalDeclaration:
+		for _, v := range n.Identifiers {
+			variable(v)
+		}
+	case *ExpressionStmt:
+		expr(n.Expression)
+	case *EchoStmt:
+		exprs(n.Expressions)
+	case *ReturnStmt:
+		expr(n.Expression)
+	case *BreakStmt:
+		expr(n.Expression)
+	case *ContinueStmt:
+		expr(n.Expression)
+	case *ThrowStmt:
+		expr(n.Expression)
+	case *IncludeStmt:
+		exprs(n.Expressions)
+	case *ExitStmt:
+		expr(n.Expression)
+	case *FunctionCallStmt:
+		expr(n.FunctionName)
+		exprs(n.Arguments)
+	case *FunctionStmt:
+		function(n.FunctionDefinition, n.Body)
+	case *FunctionDefinition:
+		args(n.Arguments)
+		typeExpr(n.ReturnType)
+	case *FunctionArgument:
+		typeExpr(n.TypeHint)
+		variable(n.Variable)
+		expr(n.Default)
+	case *TypeExpr:
+		for _, t := range n.Types {
+			typeExpr(t)
+		}
+	case *Interface:
+		constants(n.Constants)
+		methods(n.Methods)
+	case *DeclareBlock:
+		block(n.Statements)
+	case *Class:
+		traitUses(n.TraitUses)
+		constants(n.Constants)
+		properties(n.Properties)
+		methods(n.Methods)
+	case *Trait:
+		traitUses(n.TraitUses)
+		constants(n.Constants)
+		properties(n.Properties)
+		methods(n.Methods)
+	case *Constant:
+		variable(n.Variable)
+		if e, ok := n.Value.(Expression); ok {
+			expr(e)
+		}
+	case *Property:
+		typeExpr(n.TypeHint)
+		expr(n.Initialization)
+	case *Method:
+		if n.FunctionStmt != nil {
+			function(n.FunctionDefinition, n.Body)
+		}
+	case *Block:
+		for _, s := range n.Statements {
+			stmt(s)
+		}
+	case *IfStmt:
+		expr(n.Condition)
+		stmt(n.TrueBranch)
+		stmt(n.FalseBranch)
+	case *SwitchStmt:
+		expr(n.Expression)
+		for _, c := range n.Cases {
+			expr(c.Expression)
+			f(&c.Block)
+		}
+		block(n.DefaultCase)
+	case *ForStmt:
+		exprs(n.Initialization)
+		exprs(n.Termination)
+		exprs(n.Iteration)
+		stmt(n.LoopBlock)
+	case *WhileStmt:
+		expr(n.Termination)
+		stmt(n.LoopBlock)
+	case *DoWhileStmt:
+		stmt(n.LoopBlock)
+		expr(n.Termination)
+	case *TryStmt:
+		block(n.TryBlock)
+		for _, c := range n.CatchStmts {
+			f(c)
+		}
+		block(n.FinallyBlock)
+	case *CatchStmt:
+		variable(n.CatchVar)
+		block(n.CatchBlock)
+	case *ForeachStmt:
+		expr(n.Source)
+		variable(n.Key)
+		variable(n.Value)
+		stmt(n.LoopBlock)
+	case *ListStatement:
+		for _, a := range n.Assignees {
+			if a != nil {
+				f(a)
+			}
+		}
+		expr(n.Value)
+	case *StaticVariableDeclaration:
+		exprs(n.Declarations)
+	case *NamespaceStmt:
+		block(n.Block)
+	}
+}
@@ -0,0 +1,154 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/parser"
+)
+
+func parse(t *testing.T, src string) []ast.Node {
+	nodes, errs := parser.NewParser("<?php " + src).Parse()
+	if len(errs) != 0 {
+		t.Fatalf("%q: unexpected errors: %v", src, errs)
+	}
+	return nodes
+}
+
+// typeName returns the name of the type of n without its package.
+func typeName(n ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+// names collects the names of the variables and called functions it
+// visits. It does not look inside closures.
+type names struct {
+	ast.BaseVisitor
+	list []string
+}
+
+func (v *names) VisitVariable(n *ast.Variable) {
+	if id, ok := n.Name.(*ast.Identifier); ok {
+		v.list = append(v.list, "$"+id.Value)
+	}
+}
+
+func (v *names) VisitFunctionCallExpression(n *ast.FunctionCallExpression) {
+	if id, ok := n.FunctionName.(*ast.Identifier); ok {
+		v.list = append(v.list, id.Value+"()")
+	}
+	ast.WalkChildren(v, n)
+}
+
+func (v *names) VisitAnonymousFunction(n *ast.AnonymousFunction) {}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"$a = $b + f($c);", "$a $b f() $c"},
+		{"if ($a) { g($b); } else { $c; }", "$a g() $b $c"},
+		{"foreach ($list as $k => $v) { echo $v; }", "$list $k $v $v"},
+		{"while (f(g($a))) {}", "f() g() $a"},
+		{"$f = function ($x) use ($y) { return $x; }; h();", "$f h()"},
+		{"function f(int $x = 1) { return $x; }", "$x $x"},
+		{"class A { public $p = 1; const C = 2; function m($x) { $this->n($x); } }", "$C $x $this $x"},
+		{"try { f(); } catch (E $e) { g($e); } finally { h(); }", "f() $e g() $e h()"},
+	}
+	for _, test := range tests {
+		v := &names{}
+		for _, n := range parse(t, test.src) {
+			ast.Walk(v, n)
+		}
+		if got := strings.Join(v.list, " "); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.src, got, test.want)
+		}
+	}
+}
+
+// counter counts the nodes of each type that it visits, relying on
+// BaseVisitor for all of them.
+type counter struct {
+	ast.BaseVisitor
+	calls int
+}
+
+func (v *counter) VisitFunctionCallExpression(n *ast.FunctionCallExpression) {
+	v.calls++
+	ast.WalkChildren(v, n)
+}
+
+func TestBaseVisitor(t *testing.T) {
+	src := "f(g(1), function () { return h(); }); class A { function m() { i(); } }"
+	v := &counter{}
+	for _, n := range parse(t, src) {
+		ast.Walk(v, n)
+	}
+	if v.calls != 4 {
+		t.Errorf("visited %d calls, want 4", v.calls)
+	}
+}
+
+// preorder lists the types of the nodes Inspect visits below n, with the
+// children of each in parentheses.
+func preorder(n ast.Node) string {
+	var list []string
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c == nil || c == n {
+			return c == n
+		}
+		s := typeName(c)
+		if children := preorder(c); children != "" {
+			s += "(" + children + ")"
+		}
+		list = append(list, s)
+		return false
+	})
+	return strings.Join(list, " ")
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"$a + 1;", "BinaryExpression(Variable(Identifier) Literal)"},
+		{"f(...$a);", "FunctionCallExpression(Identifier CallArgument(Variable(Identifier)))"},
+		{
+			"function f(?int &$a = 1, ...$b): string {}",
+			"FunctionDefinition(FunctionArgument(TypeExpr(TypeExpr) Variable(Identifier) Literal) FunctionArgument(Variable(Identifier)) TypeExpr) Block",
+		},
+		{
+			"class A { const C = 1; private int|string $p = 2; }",
+			"Constant(Variable(Identifier) Literal) Property(TypeExpr(TypeExpr TypeExpr) Literal)",
+		},
+		{
+			"$f = function ($x) use (&$y): int { return $x; };",
+			"AssignmentExpression(Variable(Identifier) AnonymousFunction(FunctionArgument(Variable(Identifier)) FunctionArgument(Variable(Identifier)) TypeExpr Block(ReturnStmt(Variable(Identifier)))))",
+		},
+	}
+	for _, test := range tests {
+		n := parse(t, test.src)[0]
+		if got := preorder(n); got != test.want {
+			t.Errorf("%q: got\n\t%s\nwant\n\t%s", test.src, got, test.want)
+		}
+	}
+}
+
+func TestInspectPrune(t *testing.T) {
+	var visited []string
+	ast.Inspect(parse(t, "f(g(1)); h();")[0], func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		visited = append(visited, typeName(n))
+		_, call := n.(*ast.FunctionCallExpression)
+		return !call
+	})
+	if got, want := strings.Join(visited, " "), "ExpressionStmt FunctionCallExpression"; got != want {
+		t.Errorf("visited %s, want %s", got, want)
+	}
+}
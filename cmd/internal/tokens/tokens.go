@@ -0,0 +1,82 @@
+// Package tokens prints the tokens of PHP source code in the format shared by
+// the phplex command and phpast -tokens.
+package tokens
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jxwr/php-parser/lexer"
+	"github.com/jxwr/php-parser/token"
+)
+
+// Fprint writes each token the lexer finds in src to w on a line of its own,
+// whitespace and comments included, giving its position, its name, its
+// category and its value:
+//
+//	3:1-3:9	function	keyword	"function"
+//
+// If asJSON is set, the tokens are instead written as a JSON array of
+// objects with "token", "type", "pos", "end" and "value" members, the
+// positions being objects with "line", "column" and "offset" members.
+//
+// Printing stops after an error token, in which case lexErr is set.
+func Fprint(w io.Writer, filename, src string, asJSON bool) (lexErr bool, err error) {
+	if asJSON {
+		fmt.Fprint(w, "[")
+	}
+	l := lexer.NewLexerFile(filename, src)
+	for i, n := l.Next(), 0; i.Typ != token.EOF; i, n = l.Next(), n+1 {
+		if asJSON {
+			if n > 0 {
+				fmt.Fprint(w, ",")
+			}
+			data, err := json.Marshal(newItem(i))
+			if err != nil {
+				return false, err
+			}
+			fmt.Fprintf(w, "\n  %s", data)
+		} else {
+			fmt.Fprintf(w, "%s-%s\t%v\t%v\t%q\n", short(i.Begin), short(i.End), i.Typ, i.Typ.Type(), i.Val)
+		}
+		if i.Typ == token.Error {
+			lexErr = true
+			break
+		}
+	}
+	if asJSON {
+		fmt.Fprint(w, "\n]\n")
+	}
+	return lexErr, nil
+}
+
+// short formats a position without its file name.
+func short(p token.Position) string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// item is the JSON encoding of a token.
+type item struct {
+	Token string   `json:"token"`
+	Type  string   `json:"type"`
+	Pos   position `json:"pos"`
+	End   position `json:"end"`
+	Value string   `json:"value"`
+}
+
+type position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+func newItem(i token.Item) item {
+	return item{
+		Token: i.Typ.String(),
+		Type:  i.Typ.Type().String(),
+		Pos:   position{i.Begin.Line, i.Begin.Column, i.Begin.Position},
+		End:   position{i.End.Line, i.End.Column, i.End.Position},
+		Value: i.Val,
+	}
+}
@@ -0,0 +1,64 @@
+package tokens
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFprint(t *testing.T) {
+	tests := []struct {
+		src    string
+		asJSON bool
+		want   string
+		lexErr bool
+	}{
+		{
+			"<?php\n// hi\necho $é;",
+			false,
+			"1:1-1:6\tPHP Begin\tkeyword\t\"<?php\"\n" +
+				"1:6-2:1\tSpace\twhitespace\t\"\\n\"\n" +
+				"2:1-2:6\t/* */\tcomment\t\"// hi\"\n" +
+				"2:6-3:1\tSpace\twhitespace\t\"\\n\"\n" +
+				"3:1-3:5\techo\tkeyword\t\"echo\"\n" +
+				"3:5-3:6\tSpace\twhitespace\t\" \"\n" +
+				"3:6-3:7\t$\toperator\t\"$\"\n" +
+				"3:7-3:8\tidentifier\tidentifier\t\"é\"\n" +
+				"3:8-3:9\t;\tmarker\t\";\"\n",
+			false,
+		},
+		{
+			"<?php $a",
+			true,
+			"[\n" +
+				`  {"token":"PHP Begin","type":"keyword","pos":{"line":1,"column":1,"offset":0},"end":{"line":1,"column":6,"offset":5},"value":"\u003c?php"},` + "\n" +
+				`  {"token":"Space","type":"whitespace","pos":{"line":1,"column":6,"offset":5},"end":{"line":1,"column":7,"offset":6},"value":" "},` + "\n" +
+				`  {"token":"$","type":"operator","pos":{"line":1,"column":7,"offset":6},"end":{"line":1,"column":8,"offset":7},"value":"$"},` + "\n" +
+				`  {"token":"identifier","type":"identifier","pos":{"line":1,"column":8,"offset":7},"end":{"line":1,"column":9,"offset":8},"value":"a"}` + "\n" +
+				"]\n",
+			false,
+		},
+		{"", true, "[\n]\n", false},
+		{
+			"<?php 'a",
+			false,
+			"1:1-1:6\tPHP Begin\tkeyword\t\"<?php\"\n" +
+				"1:6-1:7\tSpace\twhitespace\t\" \"\n" +
+				"1:7-1:7\tError\tinvalid\t\"unterminated string\"\n",
+			true,
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		lexErr, err := Fprint(&buf, "a.php", test.src, test.asJSON)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.src, got, test.want)
+		}
+		if lexErr != test.lexErr {
+			t.Errorf("%q: lexErr is %v, want %v", test.src, lexErr, test.lexErr)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Phpast prints the syntax tree of PHP source code, for debugging the
+// parser.
+//
+// Without an explicit path, it reads the standard input. The tree is printed
+// as an indented outline with a line for each node, giving the field of its
+// parent it is found in, its kind, its position and its scalar fields.
+//
+// Usage:
+//
+//	phpast [flags] [path ...]
+//
+// The flags are:
+//
+//	-tokens
+//		Print the tokens of the source, whitespace and comments
+//		included, instead of the tree, in the format of phplex.
+//	-json
+//		Print the tree in the JSON encoding of the ast/json package, or
+//		with -tokens, the tokens in the JSON format of phplex.
+//	-pos
+//		Print the positions of nodes. On by default.
+package main
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/ast/json"
+	"github.com/jxwr/php-parser/cmd/internal/tokens"
+	"github.com/jxwr/php-parser/parser"
+	"github.com/jxwr/php-parser/token"
+)
+
+var (
+	printTokens = flag.Bool("tokens", false, "print tokens instead of the tree")
+	asJSON      = flag.Bool("json", false, "print the tree, or the tokens, as JSON")
+	positions   = flag.Bool("pos", true, "print the positions of nodes")
+
+	exitCode = 0
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: phpast [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		process("<standard input>", os.Stdin)
+	}
+	for i, path := range flag.Args() {
+		if flag.NArg() > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+			continue
+		}
+		process(path, f)
+		f.Close()
+	}
+	os.Exit(exitCode)
+}
+
+func process(filename string, in io.Reader) {
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 2
+		return
+	}
+
+	if *printTokens {
+		lexErr, err := tokens.Fprint(os.Stdout, filename, string(src), *asJSON)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+		} else if lexErr {
+			exitCode = 1
+		}
+		return
+	}
+
+	nodes, errs := parser.NewParserFile(filename, string(src)).Parse()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+	}
+
+	if *asJSON {
+		data, err := json.MarshalNodes(nodes)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+			return
+		}
+		var buf bytes.Buffer
+		stdjson.Indent(&buf, data, "", "  ")
+		buf.WriteByte('\n')
+		buf.WriteTo(os.Stdout)
+		return
+	}
+
+	d := &dumper{seen: make(map[interface{}]bool)}
+	for _, n := range nodes {
+		d.value("", reflect.ValueOf(n))
+	}
+	d.buf.WriteTo(os.Stdout)
+}
+
+// short formats a position without its file name.
+func short(p token.Position) string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+type dumper struct {
+	buf    bytes.Buffer
+	indent int
+
+	// seen records the nodes printed so far, so that nodes appearing more
+	// than once in the tree are only printed in full the first time.
+	seen map[interface{}]bool
+}
+
+var (
+	spanType  = reflect.TypeOf(ast.Span{})
+	scopeType = reflect.TypeOf(ast.Scope{})
+	typeType  = reflect.TypeOf(ast.Type(0))
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// value prints v, which was found in the field named label.
+func (d *dumper) value(label string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			d.value(label, v.Elem())
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		if d.seen[v.Interface()] {
+			d.line(label, v.Elem().Type().Name(), span(v.Elem()), "(repeated)")
+			return
+		}
+		d.seen[v.Interface()] = true
+		d.node(label, v.Elem())
+	case reflect.Struct:
+		d.node(label, v)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			d.value(fmt.Sprintf("%s[%d]", label, i), v.Index(i))
+		}
+	}
+}
+
+// node prints the struct v with its scalar fields on a line, followed by
+// its children indented below.
+func (d *dumper) node(label string, v reflect.Value) {
+	var scalars []string
+	var children []func()
+	var fields func(v reflect.Value)
+	fields = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field, f := t.Field(i), v.Field(i)
+			name := strings.ToLower(field.Name[:1]) + field.Name[1:]
+			switch {
+			case field.PkgPath != "" && !field.Anonymous:
+			case field.Type == spanType, field.Type == scopeType:
+			case field.Name == "Parent" && field.Type == nodeType:
+			case field.Anonymous && f.Kind() == reflect.Struct && f.FieldByName("Span").Kind() == reflect.Invalid:
+				fields(f)
+			case f.Kind() == reflect.String:
+				if f.String() != "" {
+					scalars = append(scalars, fmt.Sprintf("%s=%q", name, f.String()))
+				}
+			case f.Kind() == reflect.Bool:
+				if f.Bool() {
+					scalars = append(scalars, name)
+				}
+			case f.Type() == typeType:
+				// the types the parser infers are only of interest when
+				// it knows them
+				if typ := ast.Type(f.Int()); typ != 0 && typ != ast.AnyType && typ != ast.Unknown {
+					scalars = append(scalars, fmt.Sprintf("%s=%v", name, typ))
+				}
+			case f.Kind() == reflect.Int:
+				scalars = append(scalars, fmt.Sprintf("%s=%v", name, f.Interface()))
+			case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+				if f.Len() > 0 {
+					scalars = append(scalars, fmt.Sprintf("%s=%q", name, f.Interface()))
+				}
+			default:
+				children = append(children, func() { d.value(name, f) })
+			}
+		}
+	}
+	fields(v)
+	d.line(label, v.Type().Name(), span(v), strings.Join(scalars, " "))
+	d.indent++
+	for _, c := range children {
+		c()
+	}
+	d.indent--
+}
+
+func (d *dumper) line(label, kind, span, rest string) {
+	d.buf.WriteString(strings.Repeat("  ", d.indent))
+	if label != "" {
+		d.buf.WriteString(label + ": ")
+	}
+	d.buf.WriteString(kind)
+	if span != "" && *positions {
+		d.buf.WriteString(" " + span)
+	}
+	if rest != "" {
+		d.buf.WriteString(" " + rest)
+	}
+	d.buf.WriteByte('\n')
+}
+
+// span formats the position of the node v, if it has one.
+func span(v reflect.Value) string {
+	f := v.FieldByName("Span")
+	if !f.IsValid() {
+		return ""
+	}
+	s, ok := f.Interface().(ast.Span)
+	if !ok || s.StartPos.Line == 0 {
+		return ""
+	}
+	return short(s.StartPos) + "-" + short(s.EndPos)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jxwr/php-parser/parser"
+)
+
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			"<?php $a = 1 + $b;",
+			`ExpressionStmt 1:7-1:19
+  expression: AssignmentExpression 1:7-1:18 operator="="
+    assignee: Variable 1:7-1:9
+      name: Identifier 1:8-1:9 value="a"
+    value: BinaryExpression 1:12-1:18 type=integer|float operator="+"
+      antecedent: Literal 1:12-1:13 type=float value="1"
+      subsequent: Variable 1:16-1:18
+        name: Identifier 1:17-1:18 value="b"
+`,
+		},
+		{
+			"<?php $a ?: f('x');",
+			`ExpressionStmt 1:7-1:20
+  expression: TernaryExpression 1:7-1:19
+    condition: Variable 1:7-1:9
+      name: Identifier 1:8-1:9 value="a"
+    true: Variable 1:7-1:9 (repeated)
+    false: FunctionCallExpression 1:13-1:19
+      functionName: Identifier 1:13-1:14 value="f"
+      arguments[0]: Literal 1:15-1:18 type=string value="'x'"
+`,
+		},
+		{
+			"<?php abstract class A extends B { public static $p; abstract function f(int $x); }",
+			`Class 1:7-1:84 name="A" abstract extends="B"
+  methods[0]: Method 1:54-1:82 visibility=public abstract
+    functionStmt: FunctionStmt 1:63-1:81
+      functionDefinition: FunctionDefinition 1:63-1:81 name="f"
+        arguments[0]: FunctionArgument 1:74-1:80
+          typeHint: TypeExpr 1:74-1:77 form=named name="int"
+          variable: Variable 1:78-1:80
+            name: Identifier 1:79-1:80 value="x"
+  properties[0]: Property 1:36-1:52 name="$p" visibility=public static
+`,
+		},
+	}
+	for _, test := range tests {
+		nodes, errs := parser.NewParserFile("a.php", test.src).Parse()
+		if len(errs) != 0 {
+			t.Errorf("%q: unexpected errors: %v", test.src, errs)
+			continue
+		}
+		d := &dumper{seen: make(map[interface{}]bool)}
+		for _, n := range nodes {
+			d.value("", reflect.ValueOf(n))
+		}
+		if got := d.buf.String(); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.src, got, test.want)
+		}
+	}
+}
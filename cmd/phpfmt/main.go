@@ -0,0 +1,237 @@
+// Phpfmt formats PHP source code in the PSR-12 style.
+//
+// Without an explicit path, it processes the standard input. Given a file,
+// it operates on that file; given a directory, it operates on all .php files
+// in that directory, recursively. By default, phpfmt prints the reformatted
+// sources to standard output.
+//
+// Usage:
+//
+//	phpfmt [flags] [path ...]
+//
+// The flags are:
+//
+//	-d
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different than phpfmt's, print diffs
+//		to standard output.
+//	-l
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different from phpfmt's, print its name
+//		to standard output.
+//	-w
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different from phpfmt's, overwrite it
+//		with phpfmt's version.
+//
+// Phpfmt keeps comments above and beside statements and class members, and
+// at the end of blocks. It reports an error for a file with a comment
+// anywhere else, such as within an expression, and leaves it as it is
+// rather than lose the comment.
+//
+// The -d flag needs a diff command on the path.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jxwr/php-parser/parser"
+	"github.com/jxwr/php-parser/printer"
+)
+
+var (
+	list  = flag.Bool("l", false, "list files whose formatting differs from phpfmt's")
+	write = flag.Bool("w", false, "write result to (source) file instead of stdout")
+	diffs = flag.Bool("d", false, "display diffs instead of rewriting files")
+
+	exitCode = 0
+)
+
+func report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	exitCode = 2
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: phpfmt [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "error: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		if err := processFile("<standard input>", os.Stdin, os.Stdout); err != nil {
+			report(err)
+		}
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		switch info, err := os.Stat(path); {
+		case err != nil:
+			report(err)
+		case info.IsDir():
+			walkDir(path)
+		default:
+			if err := processFile(path, nil, os.Stdout); err != nil {
+				report(err)
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func walkDir(path string) {
+	filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err == nil && isPHPFile(info) {
+			err = processFile(path, nil, os.Stdout)
+		}
+		// don't let an error stop the walk
+		if err != nil {
+			report(err)
+		}
+		return nil
+	})
+}
+
+func isPHPFile(info os.FileInfo) bool {
+	name := info.Name()
+	return !info.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".php")
+}
+
+// processFile formats the file at filename, reading it from in instead if
+// in is not nil, and writes the result to out as the flags ask.
+func processFile(filename string, in io.Reader, out io.Writer) error {
+	if in == nil {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	file, errs := parser.NewParserFile(filename, string(src)).ParseFile()
+	if len(errs) > 0 {
+		// the errors are positioned, and name the file themselves
+		for _, err := range errs[:len(errs)-1] {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return errs[len(errs)-1]
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Format(&buf, file); err != nil {
+		return err
+	}
+	res := buf.Bytes()
+
+	if !bytes.Equal(src, res) {
+		// formatting has changed
+		if *list {
+			fmt.Fprintln(out, filename)
+		}
+		if *write {
+			info, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if *diffs {
+			d, err := diff(src, res, filename)
+			if err != nil {
+				return fmt.Errorf("computing diff: %s", err)
+			}
+			fmt.Fprintf(out, "diff -u %s %s\n", filepath.ToSlash(filename+".orig"), filepath.ToSlash(filename))
+			out.Write(d)
+		}
+	}
+
+	if !*list && !*write && !*diffs {
+		_, err = out.Write(res)
+	}
+	return err
+}
+
+// diff returns the unified diff between b1 and b2, as reported by the diff
+// command.
+func diff(b1, b2 []byte, filename string) ([]byte, error) {
+	f1, err := writeTempFile("phpfmt", b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("phpfmt", b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	data, err := exec.Command("diff", "-u", f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't match;
+		// ignore that failure as long as we get output
+		return replaceTempFilenames(data, filename), nil
+	}
+	return data, err
+}
+
+func writeTempFile(prefix string, data []byte) (string, error) {
+	f, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// replaceTempFilenames replaces the temporary file names in the header of
+// a unified diff with filename.orig and filename.
+func replaceTempFilenames(diff []byte, filename string) []byte {
+	lines := bytes.SplitN(diff, []byte("\n"), 3)
+	if len(lines) < 3 || !bytes.HasPrefix(lines[0], []byte("--- ")) || !bytes.HasPrefix(lines[1], []byte("+++ ")) {
+		return diff
+	}
+	lines[0] = replaceName(lines[0], filepath.ToSlash(filename+".orig"))
+	lines[1] = replaceName(lines[1], filepath.ToSlash(filename))
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// replaceName replaces the file name in a "--- name\ttime" header line.
+func replaceName(line []byte, name string) []byte {
+	rest := line[4:]
+	if i := bytes.IndexByte(rest, '\t'); i >= 0 {
+		return append([]byte(string(line[:4])+name), rest[i:]...)
+	}
+	return []byte(string(line[:4]) + name)
+}
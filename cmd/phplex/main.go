@@ -0,0 +1,90 @@
+// Phplex prints the tokens the lexer finds in PHP source code, for
+// diagnosing lexer problems.
+//
+// Without an explicit path, it reads the standard input. Every token is
+// printed on a line of its own, whitespace and comments included, giving its
+// position, its name, its category and its value:
+//
+//	3:1-3:9	function	keyword	"function"
+//
+// Usage:
+//
+//	phplex [flags] [path ...]
+//
+// The flags are:
+//
+//	-json
+//		Print the tokens as a JSON array of objects with "token", "type",
+//		"pos", "end" and "value" members, the positions being objects
+//		with "line", "column" and "offset" members. An array is
+//		printed for each path.
+//
+// Phplex exits with status 1 if the lexer reports an error.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jxwr/php-parser/cmd/internal/tokens"
+)
+
+var (
+	asJSON = flag.Bool("json", false, "print the tokens as JSON")
+
+	exitCode = 0
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: phplex [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	out := bufio.NewWriter(os.Stdout)
+	if flag.NArg() == 0 {
+		process("<standard input>", os.Stdin, out)
+	}
+	for i, path := range flag.Args() {
+		if flag.NArg() > 1 && !*asJSON {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "%s:\n", path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+			continue
+		}
+		process(path, f, out)
+		f.Close()
+	}
+	out.Flush()
+	os.Exit(exitCode)
+}
+
+func process(filename string, in io.Reader, out io.Writer) {
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 2
+		return
+	}
+
+	lexErr, err := tokens.Fprint(out, filename, string(src), *asJSON)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 2
+	} else if lexErr {
+		exitCode = 1
+	}
+}
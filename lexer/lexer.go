@@ -1,4 +1,6 @@
-// Lexer transforms an input string into a stream of PHP tokens.
+// Lexer transforms an input string into a stream of PHP tokens. Whitespace and
+// comments are emitted along with everything else, so that the values of the
+// items in the stream add up to the whole input.
 package lexer
 
 import (
@@ -26,9 +28,23 @@ type lexer struct {
 	// of the input string.
 	pos  int
 	line int
+	// lineStart is the position in the input at which the current line
+	// begins, used to compute columns.
+	lineStart int
+	// colStart is a position on line colLine and col its column. Columns
+	// are counted on from there rather than from the start of the line for
+	// every token, which would take quadratic time on long lines.
+	colStart int
+	colLine  int
+	col      int
 	// width is the length of the current rune
 	width int
-	items chan token.Item // channel of scanned items.
+
+	// state is the state function to run for more items, nil once the
+	// input is exhausted.
+	state stateFn
+	// items holds the items scanned but not yet returned by Next.
+	items []token.Item
 
 	// input is the full input string.
 	input string
@@ -38,12 +54,18 @@ type lexer struct {
 }
 
 func NewLexer(input string) token.Stream {
+	return NewLexerFile("", input)
+}
+
+// NewLexerFile returns a lexer for input that records file as the name of
+// the file in the position of every item it emits.
+func NewLexerFile(file, input string) token.Stream {
 	l := &lexer{
 		line:  1,
 		input: input,
-		items: make(chan token.Item),
+		file:  file,
+		state: lexHTML,
 	}
-	go l.run()
 	return l
 }
 
@@ -51,17 +73,8 @@ func NewLexer(input string) token.Stream {
 // as a function that returns the next state.
 type stateFn func(*lexer) stateFn
 
-// Run lexes the input by executing state functions until
-// the state is nil. It is typically called in a goroutine.
-func (l *lexer) run() {
-	for state := lexHTML; state != nil; {
-		state = state(l)
-	}
-	close(l.items) // No more tokens will be delivered.
-}
-
-// emit gets the current token., sends it on the token. channel
-// and prepares for lexing the next token.
+// emit gets the current token., queues it to be returned by Next and
+// prepares for lexing the next token.
 func (l *lexer) emit(t token.Token) {
 	i := token.Item{
 		Typ:   t,
@@ -74,17 +87,41 @@ func (l *lexer) emit(t token.Token) {
 	l.start = l.pos
 
 	i.End = l.currentLocation()
-	l.items <- i
+	l.items = append(l.items, i)
 }
 
 func (l *lexer) currentLocation() token.Position {
-	return token.Position{Position: l.start, Line: l.line, File: l.file}
+	if l.colLine != l.line || l.colStart > l.start {
+		l.colStart, l.colLine, l.col = l.lineStart, l.line, 1
+	}
+	l.col += utf8.RuneCountInString(l.input[l.colStart:l.start])
+	l.colStart = l.start
+	return token.Position{
+		Position: l.start,
+		Line:     l.line,
+		Column:   l.col,
+		File:     l.file,
+	}
 }
 
-// nextItem returns the next token. from the input.
+// Next returns the next token. from the input, running state functions
+// until one is scanned. There is no goroutine behind the lexer, so it can
+// be dropped at any point.
 func (l *lexer) Next() token.Item {
-	Item := <-l.items
-	return Item
+	for len(l.items) == 0 {
+		if l.state == nil {
+			// Keep reporting EOF at the end of the input once lexing is done.
+			return token.Item{Typ: token.EOF, Begin: l.currentLocation(), End: l.currentLocation()}
+		}
+		l.state = l.state(l)
+	}
+	i := l.items[0]
+	if len(l.items) == 1 {
+		l.items = l.items[:0]
+	} else {
+		l.items = l.items[1:]
+	}
+	return i
 }
 
 // peek returns but does not consume the next rune in the input.
@@ -130,19 +167,17 @@ func (l *lexer) next() rune {
 	return r
 }
 
-// ignore skips over the pending input before this point.
-func (l *lexer) ignore() {
-	l.start = l.pos
-	l.incrementLines()
-}
-
+// skipSpace emits the run of whitespace at the current position, if there is
+// one, as a Space item.
 func (l *lexer) skipSpace() {
 	r := l.next()
 	for isSpace(r) {
 		r = l.next()
 	}
 	l.backup()
-	l.ignore()
+	if l.pos > l.start {
+		l.emit(token.Space)
+	}
 }
 
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
@@ -152,13 +187,16 @@ func (l *lexer) errorf(format string, args ...interface{}) stateFn {
 		End:   l.currentLocation(),
 		Val:   fmt.Sprintf(format, args...),
 	}
-	l.incrementLines()
-	l.items <- i
+	l.items = append(l.items, i)
 	return nil
 }
 
 func (l *lexer) incrementLines() {
-	l.line += strings.Count(l.input[l.lastStart:l.pos], "\n")
+	consumed := l.input[l.lastStart:l.pos]
+	if n := strings.Count(consumed, "\n"); n > 0 {
+		l.line += n
+		l.lineStart = l.lastStart + strings.LastIndex(consumed, "\n") + 1
+	}
 	l.lastStart = l.pos
 }
 
@@ -0,0 +1,154 @@
+package lexer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/token"
+)
+
+// lexAll returns every item lexed from input, up to but not including EOF.
+func lexAll(file, input string) []token.Item {
+	var items []token.Item
+	l := NewLexerFile(file, input)
+	for i := l.Next(); i.Typ != token.EOF; i = l.Next() {
+		items = append(items, i)
+		if i.Typ == token.Error {
+			break
+		}
+	}
+	return items
+}
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		input string
+		val   string // the first item with this value is checked
+		begin token.Position
+		end   token.Position
+	}{
+		{"<?php $a;", "<?php", token.Position{Line: 1, Column: 1, Position: 0}, token.Position{Line: 1, Column: 6, Position: 5}},
+		{"<?php $a;", "a", token.Position{Line: 1, Column: 8, Position: 7}, token.Position{Line: 1, Column: 9, Position: 8}},
+		{"<?php\n$a;", "a", token.Position{Line: 2, Column: 2, Position: 7}, token.Position{Line: 2, Column: 3, Position: 8}},
+		{"<?php\n\n  foo();", "foo", token.Position{Line: 3, Column: 3, Position: 9}, token.Position{Line: 3, Column: 6, Position: 12}},
+		{"<?php $a\n  = 1;", "\n  ", token.Position{Line: 1, Column: 9, Position: 8}, token.Position{Line: 2, Column: 3, Position: 11}},
+		{"<?php /* a\nb */ $c;", "c", token.Position{Line: 2, Column: 7, Position: 17}, token.Position{Line: 2, Column: 8, Position: 18}},
+		{"<html>\n<?php $a;", "<?php", token.Position{Line: 2, Column: 1, Position: 7}, token.Position{Line: 2, Column: 6, Position: 12}},
+
+		// columns count characters, while positions count bytes
+		{"<?php $é = 1;", "=", token.Position{Line: 1, Column: 10, Position: 10}, token.Position{Line: 1, Column: 11, Position: 11}},
+		{"<?php 'ü'; $b;", "b", token.Position{Line: 1, Column: 13, Position: 13}, token.Position{Line: 1, Column: 14, Position: 14}},
+		{"<?php '日本'; $b;", "b", token.Position{Line: 1, Column: 14, Position: 17}, token.Position{Line: 1, Column: 15, Position: 18}},
+		{"<?php 'ü\n'; $b;", "b", token.Position{Line: 2, Column: 5, Position: 14}, token.Position{Line: 2, Column: 6, Position: 15}},
+	}
+	for _, test := range tests {
+		found := false
+		for _, i := range lexAll("a.php", test.input) {
+			if i.Val != test.val {
+				continue
+			}
+			found = true
+			test.begin.File, test.end.File = "a.php", "a.php"
+			if i.Begin != test.begin || i.End != test.end {
+				t.Errorf("%q: %q at %v (byte %d) to %v (byte %d), want %v (byte %d) to %v (byte %d)",
+					test.input, test.val, i.Begin, i.Begin.Position, i.End, i.End.Position,
+					test.begin, test.begin.Position, test.end, test.end.Position)
+			}
+			break
+		}
+		if !found {
+			t.Errorf("%q: no item %q", test.input, test.val)
+		}
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		pos  token.Position
+		want string
+	}{
+		{token.Position{Line: 3, Column: 7}, "3:7"},
+		{token.Position{Line: 3, Column: 7, File: "a.php"}, "a.php:3:7"},
+	}
+	for _, test := range tests {
+		if got := test.pos.String(); got != test.want {
+			t.Errorf("%#v: got %q, want %q", test.pos, got, test.want)
+		}
+	}
+}
+
+func TestEOFRepeats(t *testing.T) {
+	for _, input := range []string{"", "<?php $a;", "<html>", "<?php 'abc"} {
+		l := NewLexer(input)
+		var last token.Item
+		for i := 0; i < 100; i++ {
+			if last = l.Next(); last.Typ == token.EOF {
+				break
+			}
+		}
+		if last.Typ != token.EOF {
+			t.Errorf("%q: no EOF after 100 items", input)
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if again := l.Next(); again != last {
+				t.Errorf("%q: item %v after EOF, want %v", input, again, last)
+			}
+		}
+	}
+}
+
+func TestValuesCoverInput(t *testing.T) {
+	inputs := []string{
+		"<?php $a = 1; // one\n/* two */ echo \"$a {$b[1]}\";",
+		"<html>\n<?php if ($a): ?>\n<p>a</p>\n<?php endif; ?>\n",
+		"<?php\n$a = <<<EOT\n  text $a\nEOT;\n$b = <<<'EOT'\nraw\nEOT;\n",
+		"<?php\r\n\t$é   =\t'日本';\r\n",
+	}
+	files, _ := filepath.Glob("../test/php-files/*.php")
+	for _, name := range files {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(b))
+	}
+	for _, input := range inputs {
+		var b strings.Builder
+		for _, i := range lexAll("", input) {
+			if i.Typ == token.Error {
+				t.Errorf("%.40q: %s", input, i.Val)
+			}
+			b.WriteString(i.Val)
+		}
+		if b.String() != input {
+			t.Errorf("%.40q: values add up to %.40q", input, b.String())
+		}
+	}
+}
+
+func TestDropLexer(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		NewLexer("<?php $a = 1; $b = 2;").Next()
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines left behind by dropped lexers", after-before)
+	}
+}
+
+func TestLongLineColumns(t *testing.T) {
+	const n = 50000
+	input := "<?php\n$a = 'é'" + strings.Repeat(" . 'é'", n) + ";\n$b;"
+	items := lexAll("", input)
+	semicolon, b := items[len(items)-5], items[len(items)-2]
+	if want := 8 + 6*n + 1; semicolon.Val != ";" || semicolon.Begin.Column != want {
+		t.Errorf("%q at column %d, want ; at %d", semicolon.Val, semicolon.Begin.Column, want)
+	}
+	if b.Val != "b" || b.Begin.Line != 3 || b.Begin.Column != 2 {
+		t.Errorf("%q at %v, want b at 3:2", b.Val, b.Begin)
+	}
+}
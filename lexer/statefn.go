@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/jxwr/php-parser/token"
 )
@@ -62,13 +63,10 @@ func lexPHP(l *lexer) stateFn {
 
 	if r := l.peek(); unicode.IsDigit(r) {
 		return lexNumberLiteral
-	} else if r == '.' {
-		l.next() // must advance because we only peeked before
-		secondR := l.peek()
-		l.backup()
-		if unicode.IsDigit(secondR) {
-			return lexNumberLiteral
-		}
+	} else if r == '.' && l.pos+1 < len(l.input) && unicode.IsDigit(rune(l.input[l.pos+1])) {
+		// a number like .5; peeking past the dot with next and backup
+		// would lose the dot at the end of the input
+		return lexNumberLiteral
 	}
 
 	if strings.HasPrefix(l.input[l.pos:], "<<<") {
@@ -120,8 +118,7 @@ func lexPHP(l *lexer) stateFn {
 				break
 			}
 			l.pos += len(tokenString)
-			if isKw && l.accept(alphabet+underscore+digits) {
-				l.backup() // to account for the character consumed by accept
+			if isKw && isNameRune(l.peek()) {
 				l.pos -= len(tokenString)
 				break
 			}
@@ -130,11 +127,23 @@ func lexPHP(l *lexer) stateFn {
 		}
 	}
 
-	l.acceptRun(alphabet + underscore + digits + "\\")
+	for r := l.next(); isNameRune(r) || r == '\\'; r = l.next() {
+	}
+	l.backup()
+	if l.pos == l.start {
+		return l.errorf("unexpected character %q", l.peek())
+	}
 	l.emit(token.Identifier)
 	return lexPHP
 }
 
+// isNameRune reports whether r may appear in a name. Like PHP, this allows
+// any character outside of ASCII.
+func isNameRune(r rune) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' ||
+		'0' <= r && r <= '9' || r >= utf8.RuneSelf
+}
+
 func lexNumberLiteral(l *lexer) stateFn {
 	if l.accept("0") {
 		// binary?
@@ -188,6 +197,8 @@ func lexSingleQuotedStringLiteral(l *lexer) stateFn {
 		case '\'':
 			l.emit(token.StringLiteral)
 			return lexPHP
+		case eof:
+			return l.errorf("unterminated string")
 		}
 	}
 }
@@ -202,6 +213,8 @@ func lexDoubleQuotedStringLiteral(l *lexer) stateFn {
 		case '"':
 			l.emit(token.StringLiteral)
 			return lexPHP
+		case eof:
+			return l.errorf("unterminated string")
 		}
 	}
 }
@@ -225,9 +238,11 @@ func lexPHPEnd(l *lexer) stateFn {
 	return lexHTML
 }
 
+// lexLineComment emits a comment running to the end of the line, which is
+// left for lexPHP to skip as whitespace.
 func lexLineComment(l *lexer) stateFn {
-	lineLength := strings.Index(l.input[l.pos:], "\n") + 1
-	if lineLength == 0 {
+	lineLength := strings.Index(l.input[l.pos:], "\n")
+	if lineLength == -1 {
 		// this is the last line, so lex until the end
 		lineLength = len(l.input[l.pos:])
 	}
@@ -236,7 +251,7 @@ func lexLineComment(l *lexer) stateFn {
 		lineLength = phpEndLength
 	}
 	l.pos += lineLength
-	l.ignore()
+	l.emit(token.Comment)
 	return lexPHP
 }
 
@@ -247,14 +262,16 @@ func lexBlockComment(l *lexer) stateFn {
 		commentLength = len(l.input[l.pos:])
 	}
 	l.pos += commentLength
-	l.ignore()
+	l.emit(token.Comment)
 	return lexPHP
 }
 
 func lexDoc(l *lexer) stateFn {
 	var nowDoc bool
 	l.pos += len("<<<")
-	l.skipSpace()
+	// the opening <<< stays part of the literal, so spaces are accepted
+	// rather than skipped
+	l.acceptRun(" \t")
 	if strings.HasPrefix(l.input[l.pos:], "'") {
 		nowDoc = true
 		l.pos += len("'")
@@ -272,7 +289,9 @@ func lexDoc(l *lexer) stateFn {
 	}
 	l.accept("\n")
 	for !strings.HasPrefix(l.input[l.pos:], endMarker) {
-		l.next()
+		if l.next() == eof {
+			return l.errorf("unterminated heredoc")
+		}
 	}
 	l.pos += len(endMarker)
 	l.emit(token.StringLiteral)
@@ -7,10 +7,13 @@ import (
 
 func (p *Parser) parseArrayLookup(e ast.Expression) ast.Expression {
 	p.expectCurrent(token.ArrayLookupOperatorLeft, token.BlockBegin)
+	begin := nodePos(e, p.current.Begin)
 	switch Typ := p.peek().Typ; Typ {
 	case token.ArrayLookupOperatorRight, token.BlockBegin:
 		p.expect(token.ArrayLookupOperatorRight, token.BlockEnd)
-		return &ast.ArrayAppendExpression{Array: e}
+		expr := &ast.ArrayAppendExpression{Array: e}
+		p.setSpan(expr, begin)
+		return expr
 	}
 	p.next()
 	expr := &ast.ArrayLookupExpression{
@@ -18,6 +21,7 @@ func (p *Parser) parseArrayLookup(e ast.Expression) ast.Expression {
 		Index: p.parseExpression(),
 	}
 	p.expect(token.ArrayLookupOperatorRight, token.BlockEnd)
+	p.setSpan(expr, begin)
 	return expr
 }
 
@@ -25,6 +29,7 @@ func (p *Parser) parseArrayDeclaration() ast.Expression {
 	var endType token.Token
 	pairs := make([]ast.ArrayPair, 0)
 	p.expectCurrent(token.Array, token.ArrayLookupOperatorLeft)
+	begin := p.current.Begin
 	switch p.current.Typ {
 	case token.Array:
 		p.expect(token.OpenParen)
@@ -45,34 +50,49 @@ ArrayLoop:
 		case token.Comma:
 			p.expect(token.Comma)
 		case endType:
-			pairs = append(pairs, ast.ArrayPair{Key: key, Value: Val})
+			pairs = append(pairs, newArrayPair(key, Val))
 			break ArrayLoop
 		case token.ArrayKeyOperator:
 			p.expect(token.ArrayKeyOperator)
 			key = Val
 			Val = p.parseNextExpression()
 			if p.peek().Typ == endType {
-				pairs = append(pairs, ast.ArrayPair{Key: key, Value: Val})
+				pairs = append(pairs, newArrayPair(key, Val))
 				break ArrayLoop
 			}
 			p.expect(token.Comma)
 		default:
-			p.errorf("expected => or ,")
-			return nil
+			p.next()
+			p.abortf("expected => or ,")
 		}
-		pairs = append(pairs, ast.ArrayPair{Key: key, Value: Val})
+		pairs = append(pairs, newArrayPair(key, Val))
 	}
 	p.expect(endType)
-	return &ast.ArrayExpression{Pairs: pairs}
+	expr := &ast.ArrayExpression{Pairs: pairs}
+	p.setSpan(expr, begin)
+	return expr
 }
 
-func (p *Parser) parseList() ast.Statement {
+// newArrayPair returns a pair spanning from its key, if any, to its value.
+func newArrayPair(key, value ast.Expression) ast.ArrayPair {
+	pair := ast.ArrayPair{Key: key, Value: value}
+	if value != nil {
+		pair.SetSpan(nodePos(key, value.Pos()), value.End())
+	}
+	return pair
+}
+
+func (p *Parser) parseList() *ast.ListStatement {
+	begin := p.current.Begin
 	l := &ast.ListStatement{
 		Assignees: make([]ast.Assignable, 0),
 	}
 	p.expect(token.OpenParen)
 	for {
+		// a skipped element is kept as nil so that the others keep
+		// their positions
 		if p.accept(token.Comma) {
+			l.Assignees = append(l.Assignees, nil)
 			continue
 		}
 		if p.peek().Typ == token.CloseParen {
@@ -90,10 +110,15 @@ func (p *Parser) parseList() ast.Statement {
 		}
 		p.expect(token.Comma)
 	}
+	// trailing skipped elements assign nothing
+	for len(l.Assignees) > 0 && l.Assignees[len(l.Assignees)-1] == nil {
+		l.Assignees = l.Assignees[:len(l.Assignees)-1]
+	}
 	p.expect(token.CloseParen)
 	p.expect(token.AssignmentOperator)
 	l.Operator = p.current.Val
 	l.Value = p.parseNextExpression()
+	p.setSpan(l, begin)
 	return l
 
 }
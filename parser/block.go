@@ -8,11 +8,12 @@ import (
 func (p *Parser) parseBlock() *ast.Block {
 	p.expect(token.BlockBegin)
 	b := p.parseStatementsUntil(token.BlockEnd)
-	p.expectCurrent(token.BlockEnd)
+	p.expectBlockEnd(token.BlockEnd)
 	return b
 }
 
 func (p *Parser) parseStatementsUntil(endTokens ...token.Token) *ast.Block {
+	begin := p.current.Begin
 	block := &ast.Block{}
 	breakTypes := map[token.Token]bool{}
 	for _, Typ := range endTokens {
@@ -20,17 +21,15 @@ func (p *Parser) parseStatementsUntil(endTokens ...token.Token) *ast.Block {
 	}
 	for {
 		p.next()
-		if _, ok := breakTypes[p.current.Typ]; ok {
+		if _, ok := breakTypes[p.current.Typ]; ok || p.current.Typ == token.EOF {
 			break
 		}
 		stmt := p.parseStmt()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
-		if p.current.Typ == token.EOF {
-			break
-		}
 	}
+	p.setSpan(block, begin)
 	return block
 }
 
@@ -6,6 +6,7 @@ import (
 )
 
 func (p *Parser) parseIf() *ast.IfStmt {
+	begin := p.current.Begin
 	p.expect(token.OpenParen)
 	n := &ast.IfStmt{}
 	n.Condition = p.parseNextExpression()
@@ -13,7 +14,9 @@ func (p *Parser) parseIf() *ast.IfStmt {
 
 	p.next()
 	n.TrueBranch = p.parseControlBlock(token.EndIf, token.ElseIf, token.Else)
-	n.FalseBranch = &ast.Block{}
+	noElse := &ast.Block{}
+	noElse.SetSpan(p.current.End, p.current.End)
+	n.FalseBranch = noElse
 
 	blockStyle := false
 	switch p.current.Typ {
@@ -39,47 +42,51 @@ func (p *Parser) parseIf() *ast.IfStmt {
 		}
 	}
 
+	p.setSpan(n, begin)
 	return n
 }
 
 func (p *Parser) parseWhile() ast.Statement {
+	begin := p.current.Begin
 	p.expect(token.OpenParen)
 	term := p.parseNextExpression()
 	p.expect(token.CloseParen)
 	p.next()
 	block := p.parseControlBlock(token.EndWhile)
-	return &ast.WhileStmt{
+	stmt := &ast.WhileStmt{
 		Termination: term,
 		LoopBlock:   block,
 	}
+	p.setSpan(stmt, begin)
+	return stmt
 }
 
 func (p *Parser) parseForeach() ast.Statement {
+	begin := p.current.Begin
 	stmt := &ast.ForeachStmt{}
 	p.expect(token.OpenParen)
 	stmt.Source = p.parseNextExpression()
 	p.expect(token.AsOperator)
-	if p.peek().Typ == token.AmpersandOperator {
-		p.expect(token.AmpersandOperator)
-	}
+	byRef := p.accept(token.AmpersandOperator)
 	p.expect(token.VariableOperator)
+	varBegin := p.current.Begin
 	p.next()
-	first := ast.NewVariable(p.current.Val)
+	first := p.newVariable(varBegin)
 	if p.peek().Typ == token.ArrayKeyOperator {
-		stmt.Key = first
+		stmt.Key, stmt.KeyByRef = first, byRef
 		p.expect(token.ArrayKeyOperator)
-		if p.peek().Typ == token.AmpersandOperator {
-			p.expect(token.AmpersandOperator)
-		}
+		stmt.ValueByRef = p.accept(token.AmpersandOperator)
 		p.expect(token.VariableOperator)
+		varBegin = p.current.Begin
 		p.next()
-		stmt.Value = ast.NewVariable(p.current.Val)
+		stmt.Value = p.newVariable(varBegin)
 	} else {
-		stmt.Value = first
+		stmt.Value, stmt.ValueByRef = first, byRef
 	}
 	p.expect(token.CloseParen)
 	p.next()
 	stmt.LoopBlock = p.parseControlBlock(token.EndForeach)
+	p.setSpan(stmt, begin)
 	return stmt
 }
 
@@ -92,6 +99,7 @@ func (p *Parser) parseControlBlock(end ...token.Token) ast.Statement {
 }
 
 func (p *Parser) parseFor() ast.Statement {
+	begin := p.current.Begin
 	stmt := &ast.ForStmt{}
 	p.expect(token.OpenParen)
 	stmt.Initialization = p.parseExpressionsUntil(token.Comma, token.StatementEnd)
@@ -100,53 +108,106 @@ func (p *Parser) parseFor() ast.Statement {
 	p.expectCurrent(token.CloseParen)
 	p.next()
 	stmt.LoopBlock = p.parseControlBlock(token.EndFor)
+	p.setSpan(stmt, begin)
 	return stmt
 }
 
 func (p *Parser) parseDo() ast.Statement {
+	begin := p.current.Begin
 	block := p.parseBlock()
 	p.expect(token.While)
 	p.expect(token.OpenParen)
 	term := p.parseNextExpression()
 	p.expect(token.CloseParen)
 	p.expectStmtEnd()
-	return &ast.DoWhileStmt{
+	stmt := &ast.DoWhileStmt{
 		Termination: term,
 		LoopBlock:   block,
 	}
+	p.setSpan(stmt, begin)
+	return stmt
+}
+
+// parseTry parses a try statement, whose block must be followed by at least
+// one catch clause or by a finally block.
+func (p *Parser) parseTry() ast.Statement {
+	begin := p.current.Begin
+	stmt := &ast.TryStmt{}
+	stmt.TryBlock = p.parseBlock()
+	for p.accept(token.Catch) {
+		stmt.CatchStmts = append(stmt.CatchStmts, p.parseCatch())
+	}
+	if p.accept(token.Finally) {
+		stmt.FinallyBlock = p.parseBlock()
+	} else if len(stmt.CatchStmts) == 0 {
+		p.expect(token.Catch, token.Finally)
+	}
+	p.setSpan(stmt, begin)
+	return stmt
+}
+
+// parseCatch parses a catch clause, which catches one or more classes
+// separated by | and may leave out the variable the exception is assigned
+// to.
+func (p *Parser) parseCatch() *ast.CatchStmt {
+	begin := p.current.Begin
+	caught := &ast.CatchStmt{}
+	p.expect(token.OpenParen)
+	for {
+		p.expect(token.Identifier)
+		caught.CatchType = append(caught.CatchType, p.current.Val)
+		if !p.accept(token.BitwiseOrOperator) {
+			break
+		}
+	}
+	if p.accept(token.VariableOperator) {
+		varBegin := p.current.Begin
+		p.expect(token.Identifier)
+		caught.CatchVar = p.newVariable(varBegin)
+	}
+	p.expect(token.CloseParen)
+	caught.CatchBlock = p.parseBlock()
+	p.setSpan(caught, begin)
+	return caught
 }
 
 func (p *Parser) parseSwitch() ast.Statement {
+	begin := p.current.Begin
 	stmt := ast.SwitchStmt{}
 	p.expect(token.OpenParen)
-	stmt.Expression = p.parseExpression()
-	p.expectCurrent(token.CloseParen)
+	stmt.Expression = p.parseNextExpression()
+	p.expect(token.CloseParen)
 	p.expect(token.BlockBegin, token.TernaryOperator2)
 	p.next()
 	for {
 		switch p.current.Typ {
 		case token.Case:
+			caseBegin := p.current.Begin
 			expr := p.parseNextExpression()
 			p.expect(token.TernaryOperator2, token.StatementEnd)
 			p.next()
-			stmt.Cases = append(stmt.Cases, &ast.SwitchCase{
+			c := &ast.SwitchCase{
 				Expression: expr,
 				Block:      *(p.parseSwitchBlock()),
-			})
+			}
+			p.setSpanBefore(c, caseBegin)
+			stmt.Cases = append(stmt.Cases, c)
 		case token.Default:
 			p.expect(token.TernaryOperator2, token.StatementEnd)
 			p.next()
 			stmt.DefaultCase = p.parseSwitchBlock()
-		case token.BlockEnd, token.EndSwitch:
+		case token.BlockEnd, token.EndSwitch, token.EOF:
+			p.expectBlockEnd(token.BlockEnd, token.EndSwitch)
+			p.setSpan(&stmt, begin)
 			return &stmt
 		default:
-			p.errorf("Unexpected token. in switch statement:", p.current)
-			return nil
+			p.abortf("Unexpected token in switch statement: %s", p.current)
 		}
 	}
 }
 
 func (p *Parser) parseSwitchBlock() *ast.Block {
+	begin := p.current.Begin
 	needBlockEnd := false
 	if p.current.Typ == token.BlockBegin {
 		needBlockEnd = true
@@ -164,12 +225,12 @@ stmtLoop:
 				p.next()
 			}
 			fallthrough
-		case token.Case, token.Default, token.EndSwitch:
+		case token.Case, token.Default, token.EndSwitch, token.EOF:
 			break stmtLoop
 		default:
 			stmt := p.parseStmt()
 			if stmt == nil {
-				p.errorf("Invalid statement in switch block", p.current)
+				p.errorf("Invalid statement in switch block: %s", p.current)
 				break stmtLoop
 			}
 			block.Statements = append(block.Statements, stmt)
@@ -179,10 +240,12 @@ stmtLoop:
 	if needBlockEnd {
 		p.errorf("switch case needs block end")
 	}
+	p.setSpanBefore(block, begin)
 	return block
 }
 
 func (p *Parser) parseDeclareBlock() *ast.DeclareBlock {
+	begin := p.current.Begin
 	declare := &ast.DeclareBlock{Declarations: make([]string, 0)}
 
 	p.expectCurrent(token.Declare)
@@ -203,6 +266,7 @@ func (p *Parser) parseDeclareBlock() *ast.DeclareBlock {
 	} else {
 		p.expect(token.StatementEnd)
 	}
+	p.setSpan(declare, begin)
 	return declare
 }
 
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jxwr/php-parser/token"
+)
+
+// Error is a syntax error found by the parser.
+type Error struct {
+	Pos   token.Position
+	Found token.Item // the token the error was found at
+
+	// Expected lists the tokens that would have been accepted in place of
+	// Found, when the error is a missing token.
+	Expected []token.Token
+
+	Msg string
+
+	// Err is the cause of an error that stopped parsing early, such as
+	// ErrTooDeep, rather than a syntax error.
+	Err error
+}
+
+// Error renders the error as position: message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Unwrap returns the cause of the error, if it stopped parsing early.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList is a list of syntax errors, as returned by Parse. The zero value
+// is an empty list ready to use.
+type ErrorList []*Error
+
+// Add appends an error with the given position, token found, tokens
+// expected and message to the list.
+func (l *ErrorList) Add(pos token.Position, found token.Item, expected []token.Token, msg string) {
+	*l = append(*l, &Error{Pos: pos, Found: found, Expected: expected, Msg: msg})
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+// Less orders errors by file, line and column, and then by message.
+func (l ErrorList) Less(i, j int) bool {
+	e, f := l[i].Pos, l[j].Pos
+	switch {
+	case e.File != f.File:
+		return e.File < f.File
+	case e.Line != f.Line:
+		return e.Line < f.Line
+	case e.Column != f.Column:
+		return e.Column < f.Column
+	}
+	return l[i].Msg < l[j].Msg
+}
+
+// Sort sorts the list by position.
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// RemoveMultiples sorts the list and keeps only the first error of each
+// line, as later errors on a line usually follow from the first.
+func (l *ErrorList) RemoveMultiples() {
+	sort.Stable(l)
+	var last token.Position
+	i := 0
+	for _, e := range *l {
+		if i == 0 || e.Pos.File != last.File || e.Pos.Line != last.Line {
+			last = e.Pos
+			(*l)[i] = e
+			i++
+		}
+	}
+	*l = (*l)[:i]
+}
+
+// Error renders the first error of the list, with a count of the others.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns the list as an error, or nil if it is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// bailout is the panic that abandons the statement or class member being
+// parsed after a syntax error, so that parsing can resume after it.
+type bailout struct{}
+
+// abortf records an error like errorf and abandons the statement or class
+// member being parsed.
+func (p *Parser) abortf(format string, args ...interface{}) {
+	p.errorf(format, args...)
+	panic(bailout{})
+}
+
+// stmtStart lists the keywords that begin a statement, where parsing can
+// resume after a statement with a syntax error.
+var stmtStart = map[token.Token]bool{
+	token.Abstract:  true,
+	token.Break:     true,
+	token.Class:     true,
+	token.Continue:  true,
+	token.Do:        true,
+	token.Echo:      true,
+	token.Final:     true,
+	token.For:       true,
+	token.Foreach:   true,
+	token.Function:  true,
+	token.Global:    true,
+	token.If:        true,
+	token.Interface: true,
+	token.Namespace: true,
+	token.Return:    true,
+	token.Switch:    true,
+	token.Throw:     true,
+	token.Trait:     true,
+	token.Try:       true,
+	token.While:     true,
+}
+
+// memberStart lists the keywords that begin a class or interface member.
+var memberStart = map[token.Token]bool{
+	token.Abstract:  true,
+	token.Const:     true,
+	token.Final:     true,
+	token.Function:  true,
+	token.Private:   true,
+	token.Protected: true,
+	token.Public:    true,
+	token.Static:    true,
+	token.Use:       true,
+	token.Var:       true,
+}
+
+// skip recovers from r, the panic of a statement or class member that began
+// on the token at index start, by skipping to its end. Panics other than
+// bailouts are passed on.
+//
+// The construct is taken to end on a ; or on the closing brace of a block
+// it opened, or just before the closing brace of the enclosing block, the
+// end of the input or a keyword in follow that begins the next construct.
+// Its first token is always skipped, so that parsing makes progress.
+func (p *Parser) skip(r interface{}, start int, follow map[token.Token]bool) {
+	if _, ok := r.(bailout); !ok {
+		panic(r)
+	}
+	p.instantiation = false
+	for p.idx < start {
+		p.next()
+	}
+	depth := 0
+	for _, i := range p.previous[start:p.idx] {
+		switch i.Typ {
+		case token.BlockBegin:
+			depth++
+		case token.BlockEnd:
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	for ; ; p.next() {
+		first := p.idx == start
+		switch typ := p.current.Typ; {
+		case typ == token.EOF, typ == token.PHPEnd:
+			if !first {
+				p.backup()
+			}
+			return
+		case typ == token.BlockBegin:
+			depth++
+		case typ == token.BlockEnd && depth > 0:
+			if depth--; depth == 0 {
+				return
+			}
+		case depth > 0:
+		case typ == token.StatementEnd:
+			return
+		case first:
+		case typ == token.BlockEnd, follow[typ]:
+			p.backup()
+			return
+		}
+	}
+}
+
+// The errors that stop parsing when the input exceeds one of the limits of
+// the parser.
+var (
+	ErrTooLarge      = errors.New("input too large")
+	ErrTooManyTokens = errors.New("too many tokens")
+	ErrTooDeep       = errors.New("nesting too deep")
+)
+
+// stop is the panic that stops parsing altogether, when the context is done
+// or a limit is exceeded.
+type stop struct{ err error }
+
+func (p *Parser) stop(err error) {
+	panic(stop{err})
+}
+
+// nest records entering a nested statement or expression, stopping if the
+// nesting is too deep. Every call is paired with a deferred unnest.
+func (p *Parser) nest() {
+	p.depth++
+	if p.MaxDepth > 0 && p.depth > p.MaxDepth {
+		p.stop(ErrTooDeep)
+	}
+}
+
+func (p *Parser) unnest() {
+	p.depth--
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/token"
+)
+
+// errorList makes a list of errors from specs of the form file:line:column
+// message.
+func errorList(specs ...string) ErrorList {
+	var l ErrorList
+	for _, spec := range specs {
+		var pos token.Position
+		at := strings.SplitN(spec, " ", 2)
+		parts := strings.Split(at[0], ":")
+		pos.File = parts[0]
+		fmt.Sscan(parts[1], &pos.Line)
+		fmt.Sscan(parts[2], &pos.Column)
+		l.Add(pos, token.Item{}, nil, at[1])
+	}
+	return l
+}
+
+// specs renders l in the form errorList takes.
+func specs(l ErrorList) string {
+	s := make([]string, len(l))
+	for i, e := range l {
+		s[i] = fmt.Sprintf("%s:%d:%d %s", e.Pos.File, e.Pos.Line, e.Pos.Column, e.Msg)
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestErrorListSort(t *testing.T) {
+	tests := []struct {
+		list     []string
+		sorted   string
+		multiple string // after RemoveMultiples
+	}{
+		{nil, "", ""},
+		{
+			[]string{"a:2:1 x", "a:1:5 y", "a:1:2 z"},
+			"a:1:2 z, a:1:5 y, a:2:1 x",
+			"a:1:2 z, a:2:1 x",
+		},
+		{
+			[]string{"b:1:1 x", "a:3:1 y", "a:10:1 z"},
+			"a:3:1 y, a:10:1 z, b:1:1 x",
+			"a:3:1 y, a:10:1 z, b:1:1 x",
+		},
+		{
+			// by message at the same position, which is kept just once
+			[]string{"a:1:1 b", "a:1:1 a", "a:1:1 c"},
+			"a:1:1 a, a:1:1 b, a:1:1 c",
+			"a:1:1 a",
+		},
+		{
+			// the same line of different files
+			[]string{"b:4:2 x", "a:4:3 y", "b:4:1 z", "a:4:3 w"},
+			"a:4:3 w, a:4:3 y, b:4:1 z, b:4:2 x",
+			"a:4:3 w, b:4:1 z",
+		},
+	}
+	for _, test := range tests {
+		l := errorList(test.list...)
+		l.Sort()
+		if got := specs(l); got != test.sorted {
+			t.Errorf("%v: sorted to %s, want %s", test.list, got, test.sorted)
+		}
+		for i := 1; i < len(l); i++ {
+			if l.Less(i, i-1) {
+				t.Errorf("%v: error %d is less than the one before it", test.list, i)
+			}
+		}
+		l = errorList(test.list...)
+		l.RemoveMultiples()
+		if got := specs(l); got != test.multiple {
+			t.Errorf("%v: without multiples got %s, want %s", test.list, got, test.multiple)
+		}
+	}
+}
+
+func TestErrorListError(t *testing.T) {
+	tests := []struct {
+		list []string
+		want string
+	}{
+		{nil, "no errors"},
+		{[]string{"a:1:2 x"}, "a:1:2: x"},
+		{[]string{"a:1:2 x", "a:2:1 y", "a:3:1 z"}, "a:1:2: x (and 2 more errors)"},
+	}
+	for _, test := range tests {
+		l := errorList(test.list...)
+		if got := l.Error(); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.list, got, test.want)
+		}
+		if err := l.Err(); (err == nil) != (len(l) == 0) {
+			t.Errorf("%v: Err returned %v", test.list, err)
+		}
+	}
+}
+
+func TestErrorExpected(t *testing.T) {
+	tests := []struct {
+		src      string
+		found    string
+		expected string
+	}{
+		{"foo(1;", ";", "[Function Argument Separator]"},
+		{"class A extends {}", "{", "[identifier]"},
+		{"$a = 1", "EOF", "[;]"},
+		{"function f() {", "EOF", "[Block End]"},
+		{"$a = ;", ";", "[]"},
+	}
+	for _, test := range tests {
+		_, errs := NewParser("<?php " + test.src).Parse()
+		if len(errs) == 0 {
+			t.Errorf("%q: no errors", test.src)
+			continue
+		}
+		e := errs[0]
+		if e.Found.Val != test.found && e.Found.Typ.String() != test.found {
+			t.Errorf("%q: found %v, want %s", test.src, e.Found, test.found)
+		}
+		if got := fmt.Sprint(e.Expected); got != test.expected {
+			t.Errorf("%q: expected %s, want %s", test.src, got, test.expected)
+		}
+	}
+}
@@ -31,6 +31,7 @@ var operatorPrecedence = map[token.Token]int{
 	token.OrOperator:         6,
 	token.TernaryOperator1:   5,
 	token.TernaryOperator2:   5,
+	token.Yield:              4,
 
 	/*
 	   PHP's documentation would have this operator be at 4, but it also notes:
@@ -47,59 +48,44 @@ var operatorPrecedence = map[token.Token]int{
 	token.WrittenOrOperator:  1,
 }
 
-func (p *Parser) parseExpression() (expr ast.Expression) {
-	originalParenLev := p.parenLevel
+// parseExpression parses the expression starting at the current token,
+// leaving the parser on its last token.
+func (p *Parser) parseExpression() ast.Expression {
+	return p.parseExpressionAbove(0)
+}
+
+// parseExpressionAbove parses the operand at the current token along with
+// every following operator that binds tighter than the given precedence.
+func (p *Parser) parseExpressionAbove(precedence int) ast.Expression {
+	p.nest()
+	defer p.unnest()
+	operand := p.parseOperand()
+	if operand == nil {
+		return p.missingExpression()
+	}
+	return p.parseOperation(precedence, operand)
+}
 
+// missingExpression reports that no expression starts at the current token.
+// If the token could end an expression, as in $a = ; or f($a, ), parsing
+// goes on with an empty ast.BadExpr in place of the expression, positioned
+// at the end of the token before. Otherwise
+// the statement is abandoned.
+func (p *Parser) missingExpression() ast.Expression {
 	switch p.current.Typ {
-	case token.IgnoreErrorOperator:
-		return p.parseNextExpression()
-	case
-		token.UnaryOperator,
-		token.NegationOperator,
-		token.AmpersandOperator,
-		token.CastOperator,
-		token.SubtractionOperator,
-		token.BitwiseNotOperator:
-		op := p.current
-		expr = p.parseUnaryExpressionRight(p.parseNextExpression(), op)
-	case
-		token.ArrayLookupOperatorLeft,
-		token.Function,
-		token.NewOperator,
-		token.VariableOperator,
-		token.Array,
-		token.Identifier,
-		token.StringLiteral,
-		token.NumberLiteral,
-		token.BooleanLiteral,
-		token.Null,
-		token.Self,
-		token.Static,
-		token.Parent,
-		token.Include,
-		token.Exit,
-		token.ShellCommand:
-		expr = p.parseOperation(originalParenLev, p.parseOperand())
-	case token.OpenParen:
-		// check for a cast operator that happens to have had spaces in it, and was thus lexed incorrectly
-		if op := p.checkForCast(); op != nil {
-			expr = p.parseUnaryExpressionRight(p.parseNextExpression(), *op)
-			break
+	case token.StatementEnd, token.Comma, token.CloseParen,
+		token.ArrayLookupOperatorRight, token.BlockEnd, token.PHPEnd:
+		if p.idx > 0 {
+			p.errorf("Expected expression. Found %s", p.current)
+			// the expression is missing from right after the last token
+			p.backup()
+			bad := &ast.BadExpr{}
+			bad.SetSpan(p.current.End, p.current.End)
+			return bad
 		}
-		p.parenLevel += 1
-		p.next()
-		expr = p.parseExpression()
-		p.expect(token.CloseParen)
-		p.parenLevel -= 1
-		expr = p.parseOperation(originalParenLev, expr)
-	default:
-		p.errorf("Expected expression. Found %s", p.current)
-	}
-	if p.parenLevel != originalParenLev {
-		p.errorf("unbalanced parens: %d prev: %d", p.parenLevel, originalParenLev)
-		return
 	}
-	return
+	p.abortf("Expected expression. Found %s", p.current)
+	return nil
 }
 
 func (p *Parser) checkForCast() *token.Item {
@@ -133,39 +119,28 @@ func (p *Parser) isCastType(s string) bool {
 	return true
 }
 
-func (p *Parser) parseOperation(originalParenLevel int, lhs ast.Expression) (expr ast.Expression) {
-	p.next()
-	switch operationTypeForToken(p.current.Typ) {
-	case ignoreErrorOperation:
-		return p.parseOperation(originalParenLevel, lhs)
-	case unaryOperation:
-		expr = p.parseUnaryExpressionLeft(lhs, p.current)
-	case assignmentOperation, binaryOperation:
-		expr = p.parseBinaryOperation(lhs, p.current, originalParenLevel)
-	case ternaryOperation:
-		expr = p.parseTernaryOperation(lhs)
-	case subexpressionEndOperation:
-		if p.parenLevel == originalParenLevel {
-			p.backup()
+// parseOperation applies the operators following lhs for as long as they
+// bind tighter than the given precedence.
+func (p *Parser) parseOperation(precedence int, lhs ast.Expression) ast.Expression {
+	for {
+		operator := p.peek()
+		if next, ok := operatorPrecedence[operator.Typ]; !ok || next <= precedence {
 			return lhs
 		}
-		p.parenLevel -= 1
-		expr = lhs
-	case subexpressionBeginOperation:
-		// Check if we have a paren directly after a literal
-		if _, ok := lhs.(*ast.Literal); ok {
-			// If we do, we might be in a particular case of a function call with NULL as the function name. Let callers handle this
-			p.backup()
+		switch operationTypeForToken(operator.Typ) {
+		case unaryOperation:
+			p.next()
+			lhs = p.parseUnaryExpressionLeft(lhs, operator)
+		case assignmentOperation, binaryOperation:
+			p.next()
+			lhs = p.parseBinaryOperation(lhs, operator)
+		case ternaryOperation:
+			p.next()
+			lhs = p.parseTernaryOperation(lhs)
+		default:
 			return lhs
 		}
-		p.parenLevel += 1
-		expr = p.parseNextExpression()
-	default:
-		p.backup()
-		return lhs
 	}
-
-	return p.parseOperation(originalParenLevel, expr)
 }
 
 func (p *Parser) parseAssignmentOperation(lhs, rhs ast.Expression, operator token.Item) (expr ast.Expression) {
@@ -173,43 +148,48 @@ func (p *Parser) parseAssignmentOperation(lhs, rhs ast.Expression, operator toke
 	if !ok {
 		p.errorf("%s is not assignable", lhs)
 	}
-	expr = &ast.AssignmentExpression{
+	assign := &ast.AssignmentExpression{
 		Assignee: assignee,
 		Operator: operator.Val,
 		Value:    rhs,
 	}
-	return expr
+	p.setSpan(assign, nodePos(lhs, operator.Begin))
+	return assign
 }
 
 // parseOperand takes the current token and returns it as the simplest
-// expression for that token. That means an expression with no operators
-// except for the object operator.
+// expression for that token. That means an expression with no binary
+// operators, other than those inside parentheses. It returns nil if no
+// operand starts at the current token.
 func (p *Parser) parseOperand() (expr ast.Expression) {
 
 	// These cases must come first and not repeat
 	switch p.current.Typ {
-	case token.IgnoreErrorOperator:
-		p.next()
-		return p.parseOperand()
 	case
+		token.IgnoreErrorOperator,
 		token.UnaryOperator,
 		token.NegationOperator,
 		token.CastOperator,
+		token.AdditionOperator,
 		token.SubtractionOperator,
 		token.AmpersandOperator,
 		token.BitwiseNotOperator:
-		op := p.current
-		p.next()
-		return p.parseUnaryExpressionRight(p.parseExpression(), op)
+		return p.parsePrefixOperation(p.current)
 	case token.OpenParen:
 		if op := p.checkForCast(); op != nil {
-			p.next()
-			return p.parseUnaryExpressionRight(p.parseExpression(), *op)
+			return p.parsePrefixOperation(*op)
 		}
+		p.next()
+		expr = p.parseExpression()
+		p.expect(token.CloseParen)
+		p.next()
+		return p.parseOperandComponent(expr)
 	case token.Include:
 		return p.parseInclude()
 	case token.Function:
 		return p.parseAnonymousFunction()
+	case token.Yield:
+		return p.parseYield()
 	case token.NewOperator:
 		return p.parseInstantiation()
 	case token.ArrayLookupOperatorLeft:
@@ -218,47 +198,81 @@ func (p *Parser) parseOperand() (expr ast.Expression) {
 
 	switch p.current.Typ {
 	case token.ShellCommand:
-		return &ast.ShellCommand{Command: p.current.Val}
+		cmd := &ast.ShellCommand{Command: p.current.Val}
+		p.setSpan(cmd, p.current.Begin)
+		return cmd
 	case
 		token.StringLiteral,
 		token.BooleanLiteral,
 		token.NumberLiteral,
 		token.Null:
 		return p.parseLiteral()
-	case token.UnaryOperator:
-		expr = p.parseUnaryExpressionLeft(expr, p.current)
-		p.next()
-		return
-
 	case token.Array:
 		expr = p.parseArrayDeclaration()
 		p.next()
 	case token.VariableOperator:
 		expr = p.parseVariableOperand()
-	case token.ObjectOperator:
-		expr = p.parseObjectLookup(expr)
-		p.next()
-	case token.ArrayLookupOperatorLeft, token.BlockBegin:
-		expr = p.parseArrayLookup(expr)
-		p.next()
 	case token.Identifier, token.Exit:
 		expr = p.parseIdentifier()
 	case token.Self, token.Static, token.Parent:
 		expr = p.parseScopeResolutionFromKeyword()
 	default:
-		p.backup()
-		return
+		return nil
 	}
 
 	return p.parseOperandComponent(expr)
 }
 
+// parsePrefixOperation parses the operator op and the operand that follows
+// it. Only ! lets the operand extend past its postfix components, so that
+// if (!$a = foo()) assigns to $a.
+func (p *Parser) parsePrefixOperation(op token.Item) ast.Expression {
+	precedence := operatorPrecedence[token.UnaryOperator]
+	if op.Typ == token.NegationOperator {
+		precedence = operatorPrecedence[token.NegationOperator]
+	}
+	p.next()
+	return p.parseUnaryExpressionRight(p.parseExpressionAbove(precedence), op)
+}
+
+// parseYield parses a yield or yield from expression, starting on the yield
+// keyword, and marks the function being parsed as a generator. Its operands
+// extend over everything but the written logical operators.
+func (p *Parser) parseYield() ast.Expression {
+	begin := p.current.Begin
+	p.generator = true
+	precedence := operatorPrecedence[token.Yield]
+	if next := p.peek(); next.Typ == token.Identifier && strings.ToLower(next.Val) == "from" {
+		p.next()
+		p.next()
+		expr := &ast.YieldFromExpression{Source: p.parseExpressionAbove(precedence)}
+		p.setSpan(expr, begin)
+		return expr
+	}
+	expr := &ast.YieldExpression{}
+	switch p.peek().Typ {
+	case token.StatementEnd, token.Comma, token.CloseParen,
+		token.ArrayLookupOperatorRight, token.PHPEnd:
+		// a bare yield produces null
+	default:
+		p.next()
+		expr.Value = p.parseExpressionAbove(precedence)
+		if p.accept(token.ArrayKeyOperator) {
+			p.next()
+			expr.Key = expr.Value
+			expr.Value = p.parseExpressionAbove(precedence)
+		}
+	}
+	p.setSpan(expr, begin)
+	return expr
+}
+
 func (p *Parser) parseOperandComponent(lhs ast.Expression) (expr ast.Expression) {
 	expr = lhs
 	for {
 		switch p.current.Typ {
 		case token.UnaryOperator:
-			expr = p.parseUnaryExpressionRight(expr, p.current)
+			expr = p.parseUnaryExpressionLeft(expr, p.current)
 			return
 		case token.ObjectOperator:
 			expr = p.parseObjectLookup(expr)
@@ -278,52 +292,64 @@ func (p *Parser) parseOperandComponent(lhs ast.Expression) (expr ast.Expression)
 			return
 		}
 	}
-	return
 }
 
 func (p *Parser) parseLiteral() ast.Expression {
+	lit := &ast.Literal{Value: p.current.Val}
 	switch p.current.Typ {
 	case token.StringLiteral:
-		return &ast.Literal{Type: ast.String, Value: p.current.Val}
+		lit.Type = ast.String
 	case token.BooleanLiteral:
-		return &ast.Literal{Type: ast.Boolean, Value: p.current.Val}
+		lit.Type = ast.Boolean
 	case token.NumberLiteral:
-		return &ast.Literal{Type: ast.Float, Value: p.current.Val}
+		lit.Type = ast.Float
 	case token.Null:
 		if p.peek().Typ == token.OpenParen {
 			expr := p.parseIdentifier()
 			p.backup()
 			return expr
 		}
-		return &ast.Literal{Type: ast.Null, Value: p.current.Val}
+		lit.Type = ast.Null
+	default:
+		p.errorf("Unknown literal type")
+		return nil
 	}
-	p.errorf("Unknown literal type")
-	return nil
+	p.setSpan(lit, p.current.Begin)
+	return lit
 }
 
 func (p *Parser) parseVariable() ast.Expression {
+	p.nest()
+	defer p.unnest()
 	p.expectCurrent(token.VariableOperator)
+	begin := p.current.Begin
 	switch p.next(); {
 	case lexer.IsKeyword(p.current.Typ, p.current.Val):
 		// keywords are all valid variable names
 		fallthrough
 	case p.current.Typ == token.Identifier:
-		expr := ast.NewVariable(p.current.Val)
-		return expr
+		return p.newVariable(begin)
 	case p.current.Typ == token.BlockBegin:
 		expr := &ast.Variable{Name: p.parseNextExpression()}
 		p.expect(token.BlockEnd)
+		p.setSpan(expr, begin)
 		return expr
 	case p.current.Typ == token.VariableOperator:
-		return &ast.Variable{Name: p.parseVariable()}
+		expr := &ast.Variable{Name: p.parseVariable()}
+		p.setSpan(expr, begin)
+		return expr
 	default:
-		p.errorf("unexpected variable operand %s", p.current)
+		p.abortf("unexpected variable operand %s", p.current)
 		return nil
 	}
 }
 
 func (p *Parser) parseInclude() ast.Expression {
-	inc := &ast.Include{Expressions: make([]ast.Expression, 0)}
+	begin := p.current.Begin
+	inc := &ast.Include{
+		Operator:    p.current.Val,
+		Expressions: make([]ast.Expression, 0),
+	}
 	for {
 		inc.Expressions = append(inc.Expressions, p.parseNextExpression())
 		if p.peek().Typ != token.Comma {
@@ -331,55 +357,74 @@ func (p *Parser) parseInclude() ast.Expression {
 		}
 		p.expect(token.Comma)
 	}
+	p.setSpan(inc, begin)
 	return inc
 }
 
-func (p *Parser) parseIgnoreError() ast.Expression {
-	p.next()
-	return p.parseExpression()
-}
-
-func (p *Parser) parseNew(originalParenLev int) ast.Expression {
-	expr := p.parseInstantiation()
-	expr = p.parseOperation(originalParenLev, expr)
-	return expr
-}
-
 func (p *Parser) parseIdentifier() (expr ast.Expression) {
 	switch p.peek().Typ {
 	case token.OpenParen:
 		// Function calls are okay here because we know they came with
 		// a non-dynamic identifier.
-		expr = p.parseFunctionCall(&ast.Identifier{Value: p.current.Val})
+		expr = p.parseFunctionCall(p.parseIdentifierName())
 		p.next()
 	case token.ScopeResolutionOperator:
-		classIdent := p.current.Val
-		p.next() // get onto ::, then we get to the next expr
-		p.next()
-		expr = ast.NewClassExpression(classIdent, p.parseOperand())
-		p.next()
+		expr = p.parseClassExpression()
 	default:
-		expr = &ast.ConstantExpression{
-			Variable: ast.NewVariable(p.current.Val),
+		constant := &ast.ConstantExpression{
+			Variable: p.newVariable(p.current.Begin),
 		}
+		p.setSpan(constant, p.current.Begin)
+		expr = constant
 		p.next()
 	}
 	return expr
 }
 
+// parseIdentifierName returns the current token as an identifier.
+func (p *Parser) parseIdentifierName() *ast.Identifier {
+	ident := &ast.Identifier{Value: p.current.Val}
+	p.setSpan(ident, p.current.Begin)
+	return ident
+}
+
+// parseClassExpression parses a scope resolution on the class named by the
+// current token, leaving the parser on the token that follows it.
+func (p *Parser) parseClassExpression() ast.Expression {
+	receiver := p.parseIdentifierName()
+	p.next() // get onto ::, then we get to the next expr
+	p.next()
+	expr := &ast.ClassExpression{
+		Receiver:   receiver,
+		Expression: p.parseStaticMember(),
+	}
+	p.setSpan(expr, receiver.Pos())
+	p.next()
+	return expr
+}
+
+// parseStaticMember parses the member following a :: operator, which may be
+// the class keyword of Foo::class.
+func (p *Parser) parseStaticMember() ast.Expression {
+	if p.current.Typ == token.Class {
+		constant := &ast.ConstantExpression{
+			Variable: p.newVariable(p.current.Begin),
+		}
+		p.setSpan(constant, p.current.Begin)
+		return constant
+	}
+	return p.parseOperand()
+}
+
 // parseScopeResolutionFromKeyword specifically parses self::, static::, and parent::
 func (p *Parser) parseScopeResolutionFromKeyword() ast.Expression {
 	if p.peek().Typ == token.ScopeResolutionOperator {
-		r := p.current.Val
-		p.expect(token.ScopeResolutionOperator)
-		p.next()
-		expr := ast.NewClassExpression(r, p.parseOperand())
-		p.next()
-		return expr
+		return p.parseClassExpression()
 	}
-	// TODO Error
+	// the keyword names the class itself, as in new static
+	expr := p.parseIdentifierName()
 	p.next()
-	return nil
+	return expr
 }
 
 func (p *Parser) parseVariableOperand() ast.Expression {
@@ -392,7 +437,10 @@ func (p *Parser) parseVariableOperand() ast.Expression {
 		expr = p.parseArrayLookup(expr)
 		p.next()
 	case token.ScopeResolutionOperator:
-		expr = &ast.ClassExpression{Receiver: expr, Expression: p.parseNextExpression()}
+		p.next()
+		class := &ast.ClassExpression{Receiver: expr, Expression: p.parseStaticMember()}
+		p.setSpan(class, nodePos(expr, p.current.Begin))
+		expr = class
 		p.next()
 	case token.OpenParen:
 		p.backup()
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+func TestExpressionPrecedence(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"$a + $b * $c;", "($a + ($b * $c))"},
+		{"$a * $b + $c;", "(($a * $b) + $c)"},
+		{"$a - $b - $c;", "(($a - $b) - $c)"},
+		{"$a . $b + $c;", "(($a . $b) + $c)"},
+		{"$a < $b == $c;", "(($a < $b) == $c)"},
+		{"$a && $b || $c;", "(($a && $b) || $c)"},
+		{"$a || $b && $c;", "($a || ($b && $c))"},
+		{"$a = $b = $c;", "($a = ($b = $c))"},
+		{"$a = $b + $c;", "($a = ($b + $c))"},
+		{"$a += $b * $c;", "($a += ($b * $c))"},
+		{"$a = $b and $c;", "(($a = $b) and $c)"},
+		{"$a = $b or $c;", "(($a = $b) or $c)"},
+		{"$a ? $b : $c ? $d : $e;", "(($a ? $b : $c) ? $d : $e)"},
+		{"$a = $b ? $c : $d;", "($a = ($b ? $c : $d))"},
+		{"$a || $b ? $c : $d;", "(($a || $b) ? $c : $d)"},
+		{"($a + $b) * $c;", "(($a + $b) * $c)"},
+		{"$a * ($b + $c) - $d;", "(($a * ($b + $c)) - $d)"},
+		{"-$a + $b;", "((-$a) + $b)"},
+		{"$b = +1;", "($b = (+1))"},
+		{"$a = $b + +$c;", "($a = ($b + (+$c)))"},
+		{"$a - -$b;", "($a - (-$b))"},
+		{"!$a && $b;", "((!$a) && $b)"},
+		{"!$a = foo();", "(!($a = foo()))"},
+		{"$i++ + $j;", "(($i++) + $j)"},
+		{"(int) $a + $b;", "(((int)$a) + $b)"},
+		{"@foo() . $a;", "((@foo()) . $a)"},
+		{"$a instanceof B && $c;", "(($a instanceof B) && $c)"},
+		{"$a->b + $c->d;", "($a->b + $c->d)"},
+		{"foo($a + $b, $c) * $d;", "(foo(($a + $b), $c) * $d)"},
+		{"static::$a + 1;", "(static::$a + 1)"},
+		{"A::class . $b;", "(A::class . $b)"},
+		{"include 'a.php';", "include 'a.php'"},
+		{"list(, $b) = $c;", "(list(_, $b) = $c)"},
+	}, nil)
+}
+
+func TestYield(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"function g() { yield; }", "(yield)"},
+		{"function g() { yield $a; }", "(yield $a)"},
+		{"function g() { yield $k => $v; }", "(yield $k => $v)"},
+		{"function g() { yield $a + 1; }", "(yield ($a + 1))"},
+		{"function g() { yield $a ? $b : $c; }", "(yield ($a ? $b : $c))"},
+		{"function g() { yield $a and $b; }", "((yield $a) and $b)"},
+		{"function g() { $x = yield; }", "($x = (yield))"},
+		{"function g() { $x = yield $k => $v; }", "($x = (yield $k => $v))"},
+		{"function g() { $x = yield $a or $b; }", "(($x = (yield $a)) or $b)"},
+		{"function g() { f(yield $a); }", "f((yield $a))"},
+		{"function g() { yield from g(); }", "(yield from g())"},
+		{"function g() { yield from $a + $b; }", "(yield from ($a + $b))"},
+		{"function g() { $x = yield from g(); }", "($x = (yield from g()))"},
+	}, func(nodes []ast.Node) ast.Node {
+		return nodes[0].(*ast.FunctionStmt).Body.Statements[0]
+	})
+}
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"function g() { yield; }", true},
+		{"function g() { if ($a) { $b = yield from h(); } }", true},
+		{"function g() { return 1; }", false},
+		{"function g() { $f = function () { yield; }; }", false},
+		{"$f = function () { yield 1; };", true},
+		{"$f = function () { function h() { yield; } };", false},
+		{"class A { function m() { yield; } }", true},
+		{"class A { function m() {} }", false},
+	}
+	for _, test := range tests {
+		nodes := parseSource(t, test.src)
+		if nodes == nil {
+			continue
+		}
+		var got bool
+		switch n := nodes[0].(type) {
+		case *ast.FunctionStmt:
+			got = n.Generator
+		case *ast.ExpressionStmt:
+			got = n.Expression.(*ast.AssignmentExpression).Value.(*ast.AnonymousFunction).Generator
+		case *ast.Class:
+			got = n.Methods[0].Generator
+		}
+		if got != test.want {
+			t.Errorf("%q: Generator is %t, want %t", test.src, got, test.want)
+		}
+	}
+}
+
+func TestCallArguments(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"f($a, $b);", "f($a, $b)"},
+		{"f(...$a);", "f(...$a)"},
+		{"f($a, ...$b, ...g());", "f($a, ...$b, ...g())"},
+		{"f(...$a + $b);", "f(...($a + $b))"},
+		{"f(a: 1, b: $c + 1);", "f(a: 1, b: ($c + 1))"},
+		{"f($a, name: $b);", "f($a, name: $b)"},
+		{"f(array: 1, class: 2);", "f(array: 1, class: 2)"},
+		{"f(A::B, c ? d : e);", "f(A::B, (c ? d : e))"},
+		{"$o->m(...$a, x: 1);", "$o->m(...$a, x: 1)"},
+		{"new A(...$a, x: 1);", "new A(...$a, x: 1)"},
+	}, nil)
+}
+
+func TestMemberNames(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"$a->b;", "$a->b"},
+		{"$a->list; b();", "$a->list; b()"},
+		{"$a->class;", "$a->class"},
+		{"$a->default + 1;", "($a->default + 1)"},
+		{"$a->new();", "$a->new()"},
+		{"$a->list()->function;", "$a->list()->function"},
+	}, nil)
+
+	tests := []struct {
+		src, want string
+	}{
+		{"<?php $this->", "BadStmt"}, // the ; is missing too
+		{"<?php $a->; b();", "$a->BadExpr; b()"},
+		{"<?php f($a->);", "f($a->BadExpr)"},
+	}
+	for _, test := range tests {
+		nodes, errs := NewParser(test.src).Parse()
+		if len(errs) != 1 {
+			t.Errorf("%q: got errors %v, want one", test.src, errs)
+		}
+		if got := shapes(nodes, "; "); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.src, got, test.want)
+		}
+	}
+}
@@ -7,72 +7,101 @@ import (
 )
 
 func (p *Parser) parseFunctionStmt() *ast.FunctionStmt {
-	stmt := &ast.FunctionStmt{}
+	begin := p.current.Begin
+	stmt := &ast.FunctionStmt{Doc: p.docComment()}
 	stmt.FunctionDefinition = p.parseFunctionDefinition()
-	stmt.Body = p.parseBlock()
+	stmt.Body, stmt.Generator = p.parseFunctionBody()
+	p.setSpan(stmt, begin)
 	return stmt
 }
 
 func (p *Parser) parseFunctionDefinition() *ast.FunctionDefinition {
+	begin := p.current.Begin
 	def := &ast.FunctionDefinition{}
-	if p.peek().Typ == token.AmpersandOperator {
-		// This is a function returning a reference ... ignore this for now
-		p.next()
-	}
+	def.ByRef = p.accept(token.AmpersandOperator)
 	if !p.accept(token.Identifier) {
 		p.next()
 		if !lexer.IsKeyword(p.current.Typ, p.current.Val) {
-			p.errorf("bad function name", p.current.Val)
+			p.errorf("bad function name: %s", p.current.Val)
 		}
 	}
 	def.Name = p.current.Val
-	def.Arguments = make([]ast.FunctionArgument, 0)
+	def.Arguments = make([]*ast.FunctionArgument, 0)
 	p.expect(token.OpenParen)
-	if p.peek().Typ == token.CloseParen {
-		p.expect(token.CloseParen)
-		return def
+	if p.peek().Typ != token.CloseParen {
+		def.Arguments = append(def.Arguments, p.parseFunctionArgument())
 	}
-	def.Arguments = append(def.Arguments, p.parseFunctionArgument())
+Loop:
 	for {
 		switch p.peek().Typ {
 		case token.Comma:
 			p.expect(token.Comma)
 			def.Arguments = append(def.Arguments, p.parseFunctionArgument())
 		case token.CloseParen:
-			p.expect(token.CloseParen)
-			return def
+			break Loop
 		default:
-			p.errorf("unexpected argument separator:", p.current)
-			return def
+			p.next()
+			p.abortf("unexpected argument separator: %s", p.current)
 		}
 	}
+	p.expect(token.CloseParen)
+	def.ReturnType = p.parseReturnType()
+	p.setSpan(def, begin)
+	return def
 }
 
-func (p *Parser) parseFunctionArgument() ast.FunctionArgument {
-	arg := ast.FunctionArgument{}
-	switch p.peek().Typ {
-	case token.Identifier, token.Array, token.Self:
-		p.next()
-		arg.TypeHint = p.current.Val
+// parseReturnType parses the return type declared after the parameters of a
+// function, if there is one.
+func (p *Parser) parseReturnType() *ast.TypeExpr {
+	if !p.accept(token.TernaryOperator2) {
+		return nil
 	}
-	if p.peek().Typ == token.AmpersandOperator {
-		p.next()
+	return p.parseTypeExpr()
+}
+
+func (p *Parser) parseFunctionArgument() *ast.FunctionArgument {
+	begin := p.peek().Begin
+	arg := &ast.FunctionArgument{}
+	if p.startsType() {
+		arg.TypeHint = p.parseTypeExpr()
 	}
+	arg.ByRef = p.accept(token.AmpersandOperator)
+	arg.Variadic = p.accept(token.Ellipsis)
 	p.expect(token.VariableOperator)
+	varBegin := p.current.Begin
 	p.next()
-	arg.Variable = ast.NewVariable(p.current.Val)
+	arg.Variable = p.newVariable(varBegin)
 	if p.peek().Typ == token.AssignmentOperator {
 		p.expect(token.AssignmentOperator)
 		p.next()
 		arg.Default = p.parseExpression()
 	}
+	p.setSpan(arg, begin)
+	return arg
+}
+
+// parseClosureVariable parses a variable a closure uses, beginning at the
+// next token. It may be taken by reference, but has neither a type nor a
+// default.
+func (p *Parser) parseClosureVariable() *ast.FunctionArgument {
+	begin := p.peek().Begin
+	arg := &ast.FunctionArgument{}
+	arg.ByRef = p.accept(token.AmpersandOperator)
+	p.expect(token.VariableOperator)
+	varBegin := p.current.Begin
+	p.next()
+	arg.Variable = p.newVariable(varBegin)
+	p.setSpan(arg, begin)
 	return arg
 }
 
 func (p *Parser) parseFunctionCall(callable ast.Expression) *ast.FunctionCallExpression {
 	expr := &ast.FunctionCallExpression{}
 	expr.FunctionName = callable
-	return p.parseFunctionArguments(expr)
+	begin := nodePos(callable, p.peek().Begin)
+	p.parseFunctionArguments(expr)
+	p.setSpan(expr, begin)
+	return expr
 }
 
 func (p *Parser) parseFunctionArguments(expr *ast.FunctionCallExpression) *ast.FunctionCallExpression {
@@ -82,10 +111,10 @@ func (p *Parser) parseFunctionArguments(expr *ast.FunctionCallExpression) *ast.F
 		p.expect(token.CloseParen)
 		return expr
 	}
-	expr.Arguments = append(expr.Arguments, p.parseNextExpression())
+	expr.Arguments = append(expr.Arguments, p.parseCallArgument())
 	for p.peek().Typ != token.CloseParen {
 		p.expect(token.Comma)
-		arg := p.parseNextExpression()
+		arg := p.parseCallArgument()
 		if arg == nil {
 			break
 		}
@@ -96,10 +125,43 @@ func (p *Parser) parseFunctionArguments(expr *ast.FunctionCallExpression) *ast.F
 
 }
 
+// parseCallArgument parses the argument of a call beginning at the next
+// token. Spread and named arguments are wrapped in an ast.CallArgument.
+func (p *Parser) parseCallArgument() ast.Expression {
+	begin := p.peek().Begin
+	arg := &ast.CallArgument{}
+	switch {
+	case p.accept(token.Ellipsis):
+		arg.Spread = true
+	case p.namedArgument():
+		p.next()
+		arg.Name = p.current.Val
+		p.expect(token.TernaryOperator2)
+	default:
+		return p.parseNextExpression()
+	}
+	arg.Value = p.parseNextExpression()
+	p.setSpan(arg, begin)
+	return arg
+}
+
+// namedArgument reports whether a named argument begins at the next token,
+// which is then a name followed by a colon.
+func (p *Parser) namedArgument() bool {
+	p.next()
+	defer p.backup()
+	if p.current.Typ != token.Identifier && !lexer.IsKeyword(p.current.Typ, p.current.Val) {
+		return false
+	}
+	return p.peek().Typ == token.TernaryOperator2
+}
+
 func (p *Parser) parseAnonymousFunction() ast.Expression {
+	begin := p.current.Begin
 	f := &ast.AnonymousFunction{}
-	f.Arguments = make([]ast.FunctionArgument, 0)
-	f.ClosureVariables = make([]ast.FunctionArgument, 0)
+	f.ByRef = p.accept(token.AmpersandOperator)
+	f.Arguments = make([]*ast.FunctionArgument, 0)
+	f.ClosureVariables = make([]*ast.FunctionArgument, 0)
 	p.expect(token.OpenParen)
 	if p.peek().Typ != token.CloseParen {
 		f.Arguments = append(f.Arguments, p.parseFunctionArgument())
@@ -114,8 +176,8 @@ Loop:
 		case token.CloseParen:
 			break Loop
 		default:
-			p.errorf("unexpected argument separator:", p.current)
-			return f
+			p.next()
+			p.abortf("unexpected argument separator: %s", p.current)
 		}
 	}
 	p.expect(token.CloseParen)
@@ -124,23 +186,36 @@ Loop:
 	if p.peek().Typ == token.Use {
 		p.expect(token.Use)
 		p.expect(token.OpenParen)
-		f.ClosureVariables = append(f.ClosureVariables, p.parseFunctionArgument())
+		f.ClosureVariables = append(f.ClosureVariables, p.parseClosureVariable())
 	ClosureLoop:
 		for {
 			switch p.peek().Typ {
 			case token.Comma:
 				p.expect(token.Comma)
-				f.ClosureVariables = append(f.ClosureVariables, p.parseFunctionArgument())
+				f.ClosureVariables = append(f.ClosureVariables, p.parseClosureVariable())
 			case token.CloseParen:
 				break ClosureLoop
 			default:
-				p.errorf("unexpected argument separator:", p.current)
-				return f
+				p.next()
+				p.abortf("unexpected argument separator: %s", p.current)
 			}
 		}
 		p.expect(token.CloseParen)
 	}
 
-	f.Body = p.parseBlock()
+	f.ReturnType = p.parseReturnType()
+	f.Body, f.Generator = p.parseFunctionBody()
+	p.setSpan(f, begin)
 	return f
 }
+
+// parseFunctionBody parses the block of a function, reporting whether the
+// function is a generator, which it is when yield is used in the block
+// outside of nested functions.
+func (p *Parser) parseFunctionBody() (body *ast.Block, generator bool) {
+	outer := p.generator
+	defer func() { p.generator = outer }()
+	p.generator = false
+	body = p.parseBlock()
+	return body, p.generator
+}
@@ -0,0 +1,26 @@
+package parser
+
+import "testing"
+
+func TestParameters(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"function f($a, &$b, ...$c) {}", "function f($a, &$b, ...$c)"},
+		{"function &f(&...$a) {}", "function &f(&...$a)"},
+		{"function f(int &$a = 1, string ...$b) {}", "function f(int &$a = 1, string ...$b)"},
+		{"$f = function &($a) use ($b, &$c) {};", "($f = function &($a) use ($b, &$c))"},
+		{"$f = function (...$a) {};", "($f = function (...$a))"},
+		{"class A { function &m(&$a) {} }", "class A {public function &m(&$a)}"},
+	}, nil)
+}
+
+func TestParameterErrors(t *testing.T) {
+	testErrors(t,
+		"function f(&) {}",
+		"function f(...) {}",
+		"$f = function () use (...$a) {};",
+		"$f = function () use (int $a) {};",
+		"$f = function () use ($a = 1) {};",
+		"f(...);",
+		"f(a: );",
+	)
+}
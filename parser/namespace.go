@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+func (p *Parser) parseNamespace() *ast.NamespaceStmt {
+	begin := p.current.Begin
+	ns := &ast.NamespaceStmt{}
+	// the global namespace may only be declared in the braced form
+	if p.accept(token.Identifier) {
+		ns.Name = p.current.Val
+	}
+	if p.peek().Typ == token.BlockBegin {
+		ns.Block = p.parseBlock()
+	} else {
+		p.expectStmtEnd()
+	}
+	p.setSpan(ns, begin)
+	return ns
+}
+
+func (p *Parser) parseUse() *ast.UseStmt {
+	begin := p.current.Begin
+	stmt := &ast.UseStmt{Type: p.parseUseType(ast.UseClass)}
+	for {
+		p.expect(token.Identifier)
+		if strings.HasSuffix(p.current.Val, "\\") && p.peek().Typ == token.BlockBegin {
+			stmt.Prefix = strings.TrimSuffix(p.current.Val, "\\")
+			p.parseUseGroup(stmt)
+			break
+		}
+		stmt.Uses = append(stmt.Uses, p.parseUseClause("", stmt.Type))
+		if !p.accept(token.Comma) {
+			break
+		}
+	}
+	p.expectStmtEnd()
+	p.setSpan(stmt, begin)
+	return stmt
+}
+
+// parseUseGroup parses the braced list of a grouped use statement, in which
+// each name may carry its own function or const keyword.
+func (p *Parser) parseUseGroup(stmt *ast.UseStmt) {
+	p.expect(token.BlockBegin)
+	for p.peek().Typ != token.BlockEnd {
+		typ := p.parseUseType(stmt.Type)
+		p.expect(token.Identifier)
+		stmt.Uses = append(stmt.Uses, p.parseUseClause(stmt.Prefix, typ))
+		if !p.accept(token.Comma) {
+			break
+		}
+	}
+	p.expect(token.BlockEnd)
+}
+
+// parseUseType consumes an optional function or const keyword following use.
+func (p *Parser) parseUseType(def ast.UseType) ast.UseType {
+	switch {
+	case p.accept(token.Function):
+		return ast.UseFunction
+	case p.accept(token.Const):
+		return ast.UseConst
+	}
+	return def
+}
+
+func (p *Parser) parseUseClause(prefix string, typ ast.UseType) *ast.UseClause {
+	begin := p.current.Begin
+	clause := &ast.UseClause{Type: typ, Name: p.current.Val}
+	if prefix != "" {
+		clause.Name = prefix + "\\" + clause.Name
+	}
+	if p.accept(token.AsOperator) {
+		p.expect(token.Identifier)
+		clause.Alias = p.current.Val
+	}
+	p.setSpan(clause, begin)
+	return clause
+}
@@ -0,0 +1,39 @@
+package parser
+
+import "testing"
+
+func TestNamespace(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"namespace A;", "namespace A"},
+		{"namespace A\\B; f();", "namespace A\\B; f()"},
+		{"namespace A { f(); } namespace B { g(); }", "namespace A {f()}; namespace B {g()}"},
+		{"namespace { f(); }", "namespace {f()}"},
+		{"namespace A\\B {}", "namespace A\\B {}"},
+	}, nil)
+}
+
+func TestUse(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"use A;", "use A"},
+		{"use A\\B as C, D;", "use A\\B as C, D"},
+		{"use function A\\f;", "use function A\\f"},
+		{"use function A\\f as g, B\\h;", "use function A\\f as g, B\\h"},
+		{"use const A\\X;", "use const A\\X"},
+		{"use const A\\X as Y;", "use const A\\X as Y"},
+		{"use A\\{B, C as D};", "use A\\{A\\B, A\\C as D}"},
+		{"use A\\B\\{C\\D, E,};", "use A\\B\\{A\\B\\C\\D, A\\B\\E}"},
+		{"use function A\\{f, g as h};", "use function A\\{A\\f, A\\g as h}"},
+		{"use A\\{B, function f, const X as Y};", "use A\\{A\\B, function A\\f, const A\\X as Y}"},
+		{"namespace N; use A; f();", "namespace N; use A; f()"},
+	}, nil)
+}
+
+func TestUseErrors(t *testing.T) {
+	testErrors(t,
+		"use;",
+		"use A as;",
+		"use A\\{B;",
+		"use function;",
+		"namespace A",
+	)
+}
@@ -8,6 +8,7 @@ import (
 
 func (p *Parser) parseInstantiation() ast.Expression {
 	p.expectCurrent(token.NewOperator)
+	begin := p.current.Begin
 	p.next()
 
 	p.instantiation = true
@@ -18,22 +19,27 @@ func (p *Parser) parseInstantiation() ast.Expression {
 	if p.peek().Typ == token.OpenParen {
 		p.expect(token.OpenParen)
 		if p.peek().Typ != token.CloseParen {
-			expr.Arguments = append(expr.Arguments, p.parseNextExpression())
+			expr.Arguments = append(expr.Arguments, p.parseCallArgument())
 			for p.peek().Typ == token.Comma {
 				p.expect(token.Comma)
-				expr.Arguments = append(expr.Arguments, p.parseNextExpression())
+				expr.Arguments = append(expr.Arguments, p.parseCallArgument())
 			}
 		}
 		p.expect(token.CloseParen)
 	}
+	p.setSpan(expr, begin)
 	return expr
 }
 
 func (p *Parser) parseClass() *ast.Class {
+	begin := p.current.Begin
+	c := &ast.Class{Doc: p.docComment()}
 	if p.current.Typ == token.Abstract {
+		c.Abstract = true
 		p.expect(token.Class)
 	}
 	if p.current.Typ == token.Final {
+		c.Final = true
 		p.expect(token.Class)
 	}
 	switch p.next(); {
@@ -43,21 +49,26 @@ func (p *Parser) parseClass() *ast.Class {
 		p.errorf("unexpected variable operand %s", p.current)
 	}
 
-	name := p.current.Val
+	c.Name = p.current.Val
 	if p.peek().Typ == token.Extends {
 		p.expect(token.Extends)
 		p.expect(token.Identifier)
+		c.Extends = p.current.Val
 	}
 	if p.peek().Typ == token.Implements {
 		p.expect(token.Implements)
 		p.expect(token.Identifier)
+		c.Implements = append(c.Implements, p.current.Val)
 		for p.peek().Typ == token.Comma {
 			p.expect(token.Comma)
 			p.expect(token.Identifier)
+			c.Implements = append(c.Implements, p.current.Val)
 		}
 	}
 	p.expect(token.BlockBegin)
-	return p.parseClassFields(&ast.Class{Name: name})
+	p.parseClassFields(c)
+	p.setSpan(c, begin)
+	return c
 }
 
 func (p *Parser) parseObjectLookup(r ast.Expression) (expr ast.Expression) {
@@ -70,19 +81,34 @@ func (p *Parser) parseObjectLookup(r ast.Expression) (expr ast.Expression) {
 		prop.Name = p.parseNextExpression()
 		p.expect(token.BlockEnd)
 	case token.VariableOperator:
-		prop.Name = p.parseExpression()
+		prop.Name = p.parseVariable()
 	case token.Identifier:
-		prop.Name = &ast.Identifier{Value: p.current.Val}
+		prop.Name = p.parseIdentifierName()
+	default:
+		if lexer.IsKeyword(p.current.Typ, p.current.Val) {
+			// keywords such as list and class name members too
+			prop.Name = p.parseIdentifierName()
+			break
+		}
+		p.errorf("Expected member name. Found %s", p.current)
+		// the name is missing from right after the ->
+		p.backup()
+		bad := &ast.BadExpr{}
+		bad.SetSpan(p.current.End, p.current.End)
+		prop.Name = bad
 	}
+	begin := nodePos(r, prop.Name.Pos())
+	p.setSpan(prop, begin)
 	expr = prop
 	switch pk := p.peek(); pk.Typ {
 	case token.OpenParen:
-		expr = &ast.MethodCallExpression{
+		call := &ast.MethodCallExpression{
 			Receiver:               r,
 			FunctionCallExpression: p.parseFunctionCall(prop.Name),
 		}
+		p.setSpan(call, begin)
+		expr = call
 	}
-	expr = p.parseOperation(p.parenLevel, expr)
 	return
 }
 
@@ -111,69 +137,213 @@ func (p *Parser) parseAbstract() bool {
 
 func (p *Parser) parseClassFields(c *ast.Class) *ast.Class {
 	// Starting on BlockBegin
-	c.Methods = make([]ast.Method, 0)
-	c.Properties = make([]ast.Property, 0)
-	for p.peek().Typ != token.BlockEnd {
-		vis, _, _, abstract := p.parseClassMemberSettings()
-		p.next()
-		switch p.current.Typ {
-		case token.Function:
-			if abstract {
-				f := p.parseFunctionDefinition()
-				m := ast.Method{
-					Visibility:   vis,
-					FunctionStmt: &ast.FunctionStmt{FunctionDefinition: f},
-				}
-				c.Methods = append(c.Methods, m)
-				p.expect(token.StatementEnd)
-			} else {
-				c.Methods = append(c.Methods, ast.Method{
-					Visibility:   vis,
-					FunctionStmt: p.parseFunctionStmt(),
-				})
-			}
-		case token.Var:
-			p.expect(token.VariableOperator)
-			fallthrough
-		case token.VariableOperator:
-			for {
-				p.expect(token.Identifier)
-				prop := ast.Property{
-					Visibility: vis,
-					Name:       "$" + p.current.Val,
-				}
-				if p.peek().Typ == token.AssignmentOperator {
-					p.expect(token.AssignmentOperator)
-					prop.Initialization = p.parseNextExpression()
-				}
-				c.Properties = append(c.Properties, prop)
-				if p.accept(token.StatementEnd) {
-					break
+	c.Methods = make([]*ast.Method, 0)
+	c.Properties = make([]*ast.Property, 0)
+	p.parseMembers(func() { p.parseClassMember(c) })
+	return c
+}
+
+// parseMembers calls member to parse each member of a class or interface
+// body, starting on its opening brace and leaving the parser on its closing
+// one. A member with a syntax error is skipped, and parsing resumes with the
+// next.
+func (p *Parser) parseMembers(member func()) {
+	for p.peek().Typ != token.BlockEnd && p.peek().Typ != token.EOF {
+		func() {
+			start := p.idx + 1
+			defer func() {
+				if r := recover(); r != nil {
+					p.skip(r, start, memberStart)
 				}
-				p.expect(token.Comma)
-				p.expect(token.VariableOperator)
-			}
-		case token.Const:
-			constant := ast.Constant{}
+			}()
+			member()
+		}()
+	}
+	p.next()
+	p.expectBlockEnd(token.BlockEnd)
+}
+
+func (p *Parser) parseClassMember(c *ast.Class) {
+	begin := p.peek().Begin
+	doc := p.docComments[p.idx+1]
+	var vis ast.Visibility
+	var static, final, abstract bool
+	// var declares a public property, without any other modifiers
+	isVar := p.accept(token.Var)
+	if isVar {
+		vis = ast.Public
+	} else {
+		vis, static, final, abstract = p.parseClassMemberSettings()
+	}
+	var typ *ast.TypeExpr
+	if p.startsType() {
+		typ = p.parseTypeExpr()
+	}
+	p.next()
+	if typ != nil && p.current.Typ != token.VariableOperator {
+		p.abortf("unexpected type declaration before %v", p.current)
+	}
+	if isVar && p.current.Typ != token.VariableOperator {
+		p.abortf("unexpected %v after var", p.current)
+	}
+	switch p.current.Typ {
+	case token.Function:
+		m := &ast.Method{
+			Visibility: vis,
+			Static:     static,
+			Final:      final,
+			Abstract:   abstract,
+		}
+		if abstract {
+			funcBegin := p.current.Begin
+			f := p.parseFunctionDefinition()
+			m.FunctionStmt = &ast.FunctionStmt{FunctionDefinition: f}
+			p.setSpan(m.FunctionStmt, funcBegin)
+			p.expect(token.StatementEnd)
+		} else {
+			m.FunctionStmt = p.parseFunctionStmt()
+		}
+		m.Doc = doc
+		p.setSpan(m, begin)
+		c.Methods = append(c.Methods, m)
+	case token.VariableOperator:
+		for {
 			p.expect(token.Identifier)
-			constant.Variable = ast.NewVariable(p.current.Val)
+			prop := &ast.Property{
+				Doc:        doc,
+				Visibility: vis,
+				Static:     static,
+				TypeHint:   typ,
+				Name:       "$" + p.current.Val,
+			}
 			if p.peek().Typ == token.AssignmentOperator {
 				p.expect(token.AssignmentOperator)
-				constant.Value = p.parseNextExpression()
+				prop.Initialization = p.parseNextExpression()
 			}
-			c.Constants = append(c.Constants, constant)
-			p.expect(token.StatementEnd)
-		default:
-			p.errorf("unexpected class member %v", p.current)
-			return c
+			p.setSpan(prop, begin)
+			c.Properties = append(c.Properties, prop)
+			if p.accept(token.StatementEnd) {
+				break
+			}
+			p.expect(token.Comma)
+			p.expect(token.VariableOperator)
+			begin = p.current.Begin
 		}
+	case token.Const:
+		constant := p.parseConstant()
+		constant.Doc = doc
+		c.Constants = append(c.Constants, constant)
+	case token.Use:
+		c.TraitUses = append(c.TraitUses, p.parseTraitUse())
+	default:
+		p.abortf("unexpected class member %v", p.current)
 	}
-	p.expect(token.BlockEnd)
-	return c
+}
+
+// parseTrait parses a trait declaration, starting on the trait keyword. Its
+// members are parsed like those of a class.
+func (p *Parser) parseTrait() *ast.Trait {
+	begin := p.current.Begin
+	t := &ast.Trait{Doc: p.docComment()}
+	p.expect(token.Identifier)
+	t.Name = p.current.Val
+	p.expect(token.BlockBegin)
+	c := &ast.Class{}
+	p.parseClassFields(c)
+	t.TraitUses = c.TraitUses
+	t.Methods = c.Methods
+	t.Properties = c.Properties
+	t.Constants = c.Constants
+	p.setSpan(t, begin)
+	return t
+}
+
+// parseTraitUse parses the use of traits in a class or trait body, starting
+// on the use keyword.
+func (p *Parser) parseTraitUse() *ast.TraitUse {
+	begin := p.current.Begin
+	use := &ast.TraitUse{}
+	for {
+		p.expect(token.Identifier)
+		use.Traits = append(use.Traits, p.current.Val)
+		if !p.accept(token.Comma) {
+			break
+		}
+	}
+	p.expect(token.StatementEnd, token.BlockBegin)
+	if p.current.Typ == token.BlockBegin {
+		for !p.accept(token.BlockEnd) {
+			use.Rules = append(use.Rules, p.parseTraitRule())
+		}
+	}
+	p.setSpan(use, begin)
+	return use
+}
+
+// parseTraitRule parses a statement of the conflict resolution block of a
+// trait use.
+func (p *Parser) parseTraitRule() *ast.TraitRule {
+	begin := p.peek().Begin
+	rule := &ast.TraitRule{Method: p.parseMethodName(), Visibility: ast.Public}
+	if p.peek().Typ == token.ScopeResolutionOperator {
+		p.next()
+		rule.Trait = rule.Method
+		rule.Method = p.parseMethodName()
+	}
+	if rule.Trait == "" {
+		// insteadof needs to know which trait the method is taken from
+		p.expect(token.AsOperator)
+	} else {
+		p.expect(token.InsteadOf, token.AsOperator)
+	}
+	if p.current.Typ == token.InsteadOf {
+		for {
+			p.expect(token.Identifier)
+			rule.InsteadOf = append(rule.InsteadOf, p.current.Val)
+			if !p.accept(token.Comma) {
+				break
+			}
+		}
+	} else {
+		rule.Visibility, rule.ChangesVisibility = p.parseVisibility()
+		if !rule.ChangesVisibility || p.peek().Typ != token.StatementEnd {
+			rule.Alias = p.parseMethodName()
+		}
+	}
+	p.expect(token.StatementEnd)
+	p.setSpan(rule, begin)
+	return rule
+}
+
+// parseMethodName parses the name of a method, which may be a keyword.
+func (p *Parser) parseMethodName() string {
+	p.next()
+	if p.current.Typ != token.Identifier && !lexer.IsKeyword(p.current.Typ, p.current.Val) {
+		p.expected(token.Identifier)
+	}
+	return p.current.Val
+}
+
+// parseConstant parses a class or interface constant declaration, starting
+// on the const keyword.
+func (p *Parser) parseConstant() *ast.Constant {
+	begin := p.current.Begin
+	constant := &ast.Constant{}
+	p.expect(token.Identifier)
+	constant.Variable = p.newVariable(p.current.Begin)
+	if p.peek().Typ == token.AssignmentOperator {
+		p.expect(token.AssignmentOperator)
+		constant.Value = p.parseNextExpression()
+	}
+	p.expect(token.StatementEnd)
+	p.setSpan(constant, begin)
+	return constant
 }
 
 func (p *Parser) parseInterface() *ast.Interface {
+	begin := p.current.Begin
 	i := &ast.Interface{
+		Doc:      p.docComment(),
 		Inherits: make([]string, 0),
 	}
 	p.expect(token.Identifier)
@@ -190,37 +360,39 @@ func (p *Parser) parseInterface() *ast.Interface {
 		}
 	}
 	p.expect(token.BlockBegin)
-	for p.peek().Typ != token.BlockEnd {
-		vis, _ := p.parseVisibility()
-		if p.peek().Typ == token.Static {
-			p.next()
-		}
-		p.next()
-		switch p.current.Typ {
-		case token.Function:
-			f := p.parseFunctionDefinition()
-			m := ast.Method{
-				Visibility:   vis,
-				FunctionStmt: &ast.FunctionStmt{FunctionDefinition: f},
-			}
-			i.Methods = append(i.Methods, m)
-			p.expect(token.StatementEnd)
-		case token.Const:
-			constant := ast.Constant{}
-			p.expect(token.Identifier)
-			constant.Variable = ast.NewVariable(p.current.Val)
-			if p.peek().Typ == token.AssignmentOperator {
-				p.expect(token.AssignmentOperator)
-				constant.Value = p.parseNextExpression()
-			}
-			i.Constants = append(i.Constants, constant)
-			p.expect(token.StatementEnd)
-		default:
-			p.errorf("unexpected interface member %v", p.current)
+	p.parseMembers(func() { p.parseInterfaceMember(i) })
+	p.setSpan(i, begin)
+	return i
+}
+
+func (p *Parser) parseInterfaceMember(i *ast.Interface) {
+	begin := p.peek().Begin
+	doc := p.docComments[p.idx+1]
+	vis, _ := p.parseVisibility()
+	static := p.accept(token.Static)
+	p.next()
+	switch p.current.Typ {
+	case token.Function:
+		funcBegin := p.current.Begin
+		f := p.parseFunctionDefinition()
+		// interface methods are implicitly abstract
+		m := &ast.Method{
+			Visibility:   vis,
+			Static:       static,
+			Abstract:     true,
+			FunctionStmt: &ast.FunctionStmt{FunctionDefinition: f, Doc: doc},
 		}
+		p.setSpan(m.FunctionStmt, funcBegin)
+		p.expect(token.StatementEnd)
+		p.setSpan(m, begin)
+		i.Methods = append(i.Methods, m)
+	case token.Const:
+		constant := p.parseConstant()
+		constant.Doc = doc
+		i.Constants = append(i.Constants, constant)
+	default:
+		p.abortf("unexpected interface member %v", p.current)
 	}
-	p.expect(token.BlockEnd)
-	return i
 }
 
 func (p *Parser) parseClassMemberSettings() (vis ast.Visibility, static, final, abstract bool) {
@@ -255,5 +427,4 @@ func (p *Parser) parseClassMemberSettings() (vis ast.Visibility, static, final,
 			return
 		}
 	}
-	return
 }
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+func TestTraitUse(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"class A { use T; }", "class A {use T}"},
+		{"class A { use T, \\N\\U; use V; }", "class A {use T, \\N\\U; use V}"},
+		{
+			"class A { use T, U { T::f insteadof U; U::f as g; h as protected; i as private j; U::k as public; } }",
+			"class A {use T, U {T::f insteadof U; U::f as g; h as protected; i as private j; U::k as public}}",
+		},
+		{"class A { use T, U, V { T::f insteadof U, V; } }", "class A {use T, U, V {T::f insteadof U, V}}"},
+		{"trait A { use T { f as g; } }", "trait A {use T {f as g}}"},
+	}, nil)
+}
+
+func TestTrait(t *testing.T) {
+	nodes := parseSource(t, `
+/** Greets. */
+trait Greeting {
+	use Base;
+	const HELLO = 'hello';
+	private static $count = 0;
+	public function greet($name) { return self::HELLO . $name; }
+	abstract protected function name();
+}`)
+	if nodes == nil {
+		return
+	}
+	want := "trait Greeting {use Base; const HELLO = 'hello'; private static $count = 0; " +
+		"public function greet($name); abstract protected function name()}"
+	if got := shape(nodes[0]); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if doc := nodes[0].(*ast.Trait).Doc; doc != "/** Greets. */" {
+		t.Errorf("got doc %q", doc)
+	}
+}
+
+func TestTraitErrors(t *testing.T) {
+	testErrors(t,
+		"trait T extends U {}",
+		"trait T implements I {}",
+		"class A { use T { f insteadof U; } }",
+		"class A { use T { T::f insteadof; } }",
+		"class A { use T { f as; } }",
+		"class A { use T { f; } }",
+		"class A { use; }",
+	)
+}
+
+func TestClassHeader(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"class A {}", "class A {}"},
+		{"class A extends B {}", "class A extends B {}"},
+		{"class A extends \\N\\B {}", "class A extends \\N\\B {}"},
+		{"class A implements I {}", "class A implements I {}"},
+		{"class A extends B implements I, \\N\\J {}", "class A extends B implements I, \\N\\J {}"},
+		{"abstract class A {}", "abstract class A {}"},
+		{"final class A extends B {}", "final class A extends B {}"},
+		{"interface I extends J, K {}", "interface I extends J, K {}"},
+	}, nil)
+	testErrors(t,
+		"class A extends {}",
+		"class A extends B, C {}",
+		"class A implements {}",
+		"class A implements I, {}",
+	)
+}
+
+func TestMemberModifiers(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"class A { function f() {} }", "class A {public function f()}"},
+		{"class A { static function f() {} }", "class A {public static function f()}"},
+		{"class A { private static function f() {} }", "class A {private static function f()}"},
+		{"class A { static protected function f() {} }", "class A {protected static function f()}"},
+		{"class A { final public function f() {} }", "class A {final public function f()}"},
+		{"class A { public final static function f() {} }", "class A {final public static function f()}"},
+		{"abstract class A { abstract function f(); }", "abstract class A {abstract public function f()}"},
+		{"abstract class A { protected abstract static function f(); }", "abstract class A {abstract protected static function f()}"},
+		{"class A { public static $p; }", "class A {public static $p}"},
+		{"class A { static private $p = 1, $q; }", "class A {private static $p = 1; private static $q}"},
+		{"class A { protected $p; }", "class A {protected $p}"},
+		{"interface I { function f(); static function g(); }", "interface I {abstract public function f(); abstract public static function g()}"},
+	}, nil)
+	testErrors(t,
+		"class A { static static function f() {} }",
+		"class A { public private $p; }",
+		"class A { final final function f() {} }",
+		"class A { abstract abstract function f(); }",
+		"class A { abstract function f() {} }",
+	)
+}
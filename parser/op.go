@@ -13,15 +13,10 @@ const (
 	binaryOperation
 	ternaryOperation
 	assignmentOperation
-	subexpressionBeginOperation
-	subexpressionEndOperation
-	ignoreErrorOperation
 )
 
 func operationTypeForToken(t token.Token) operationType {
 	switch t {
-	case token.IgnoreErrorOperator:
-		return ignoreErrorOperation
 	case token.UnaryOperator, token.BitwiseNotOperator:
 		return unaryOperation
 	case token.AdditionOperator,
@@ -44,10 +39,6 @@ func operationTypeForToken(t token.Token) operationType {
 		return ternaryOperation
 	case token.AssignmentOperator:
 		return assignmentOperation
-	case token.OpenParen:
-		return subexpressionBeginOperation
-	case token.CloseParen:
-		return subexpressionEndOperation
 	}
 	return nilOperation
 }
@@ -64,26 +55,25 @@ func (p *Parser) newBinaryOperation(operator token.Item, expr1, expr2 ast.Expres
 	case token.AmpersandOperator, token.BitwiseXorOperator, token.BitwiseOrOperator, token.BitwiseShiftOperator:
 		t = ast.AnyType
 	}
-	return &ast.BinaryExpression{
+	expr := &ast.BinaryExpression{
 		Type:       t,
 		Antecedent: expr1,
 		Subsequent: expr2,
 		Operator:   operator.Val,
 	}
+	p.setSpan(expr, nodePos(expr1, operator.Begin))
+	return expr
 }
 
-func (p *Parser) parseBinaryOperation(lhs ast.Expression, operator token.Item, originalParenLevel int) ast.Expression {
-	p.next()
-	rhs := p.parseOperand()
-	currentPrecedence := operatorPrecedence[operator.Typ]
-	for {
-		nextOperator := p.peek()
-		nextPrecedence, ok := operatorPrecedence[nextOperator.Typ]
-		if !ok || nextPrecedence < currentPrecedence {
-			break
-		}
-		rhs = p.parseOperation(originalParenLevel, rhs)
+func (p *Parser) parseBinaryOperation(lhs ast.Expression, operator token.Item) ast.Expression {
+	precedence := operatorPrecedence[operator.Typ]
+	if operator.Typ == token.AssignmentOperator {
+		// assignments are right associative, and their value extends over
+		// everything but the written logical operators
+		precedence = operatorPrecedence[token.WrittenAndOperator]
 	}
+	p.next()
+	rhs := p.parseExpressionAbove(precedence)
 	return p.newBinaryOperation(operator, lhs, rhs)
 }
 
@@ -95,26 +85,48 @@ func (p *Parser) parseTernaryOperation(lhs ast.Expression) ast.Expression {
 		truthy = p.parseNextExpression()
 	}
 	p.expect(token.TernaryOperator2)
-	falsy := p.parseNextExpression()
-	return &ast.TernaryExpression{
+	p.next()
+	falsy := p.parseExpressionAbove(operatorPrecedence[token.TernaryOperator1])
+	expr := &ast.TernaryExpression{
 		Condition: lhs,
 		True:      truthy,
 		False:     falsy,
 		Type:      ast.AnyType,
 	}
+	p.setSpan(expr, nodePos(lhs, p.current.Begin))
+	return expr
 }
 
 func (p *Parser) parseUnaryExpressionRight(operand ast.Expression, operator token.Item) ast.Expression {
-	return &ast.UnaryExpression{
+	expr := &ast.UnaryExpression{
 		Operand:  operand,
 		Operator: operator.Val,
 	}
+	setUnarySpan(expr, operand, operator)
+	return expr
 }
 
 func (p *Parser) parseUnaryExpressionLeft(operand ast.Expression, operator token.Item) ast.Expression {
-	return &ast.UnaryExpression{
+	expr := &ast.UnaryExpression{
 		Operand:   operand,
 		Operator:  operator.Val,
 		Preceding: true,
 	}
+	setUnarySpan(expr, operand, operator)
+	return expr
+}
+
+// setUnarySpan records expr as covering both its operator and its operand,
+// whichever side of the operand the operator is on.
+func setUnarySpan(expr *ast.UnaryExpression, operand ast.Expression, operator token.Item) {
+	begin, end := operator.Begin, operator.End
+	if operand != nil {
+		if operand.Pos().Position < begin.Position {
+			begin = operand.Pos()
+		}
+		if operand.End().Position > end.Position {
+			end = operand.End()
+		}
+	}
+	expr.SetSpan(begin, end)
 }
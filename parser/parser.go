@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/jxwr/php-parser/ast"
 	"github.com/jxwr/php-parser/lexer"
@@ -13,44 +16,111 @@ import (
 type Parser struct {
 	Debug       bool // Debug causes the parser to print all errors to stdout and relay any panic upon internal panic recovery.
 	PrintTokens bool // PrintTokens causes the parser to print all tokens received from the lexer to stdout.
-	MaxErrors   int  // Indicates the number of errors to allow before triggering a panic. The default is 10.
+	MaxErrors   int  // MaxErrors limits the number of errors recorded. Parsing continues past it. Zero means no limit; the default is 10.
+
+	// The limits stop parsing with an error rather than letting a
+	// pathological input exhaust memory or the stack. Zero means no limit.
+	MaxDepth  int // MaxDepth limits how deeply statements and expressions may nest. The default is 1000.
+	MaxTokens int // MaxTokens limits the number of tokens, not counting whitespace and comments.
+	MaxSize   int // MaxSize limits the length of the input in bytes.
 
 	lexer      token.Stream
+	file       string
+	input      string
+	done       <-chan struct{}
+	ctx        context.Context
+	depth      int
+	stopped    bool
 	previous   []token.Item
 	idx        int
 	current    token.Item
-	errors     []error
-	parenLevel int
+	errors     ErrorList
 	errorMap   map[int]bool
 	errorCount int
 
+	// docComments maps the index of a token to the doc comment that
+	// immediately precedes it.
+	docComments map[int]string
+
+	// lossless is set by ParseFile to keep every item read from the lexer,
+	// whitespace and comments included, in tokens.
+	lossless bool
+	tokens   []token.Item
+
 	instantiation bool
+
+	// generator is set when yield is found in the body of the function
+	// being parsed.
+	generator bool
 }
 
 // NewParser readies a parser object for the given input string.
 func NewParser(input string) *Parser {
+	return NewParserFile("", input)
+}
+
+// NewParserFile readies a parser object for the given input string, naming
+// file in the positions of all nodes and errors.
+func NewParserFile(file, input string) *Parser {
 	p := &Parser{
 		idx:       -1,
 		MaxErrors: 10,
-		lexer:     lexer.NewLexer(input),
+		MaxDepth:  1000,
+		lexer:     lexer.NewLexerFile(file, input),
+		file:      file,
+		input:     input,
 		errorMap:  make(map[int]bool),
+
+		docComments: make(map[int]string),
 	}
 	return p
 }
 
-// Parse consumes the input string to produce an AST that represents it.
-func (p *Parser) Parse() (nodes []ast.Node, errors []error) {
+// Parse consumes the input string to produce an AST that represents it. The
+// syntax errors found are returned sorted by position, with at most one for
+// each line. Parsing resumes after each error, so that the tree still covers
+// the whole input: a statement or expression that cannot be parsed is
+// replaced by an ast.BadStmt or ast.BadExpr, and a class member that cannot
+// is left out.
+func (p *Parser) Parse() ([]ast.Node, ErrorList) {
+	return p.ParseContext(context.Background())
+}
+
+// ParseContext parses the input like Parse, but gives up when ctx is done.
+// Parsing also stops when the input exceeds one of the limits of the parser.
+// The error that stopped it, which wraps ctx.Err() or is one of ErrTooLarge,
+// ErrTooManyTokens and ErrTooDeep, is the last in the list.
+func (p *Parser) ParseContext(ctx context.Context) (nodes []ast.Node, errors ErrorList) {
+	p.ctx, p.done = ctx, ctx.Done()
+	var stopped error
 	defer func() {
 		if r := recover(); r != nil {
-			errors = append([]error{fmt.Errorf("%s", r)}, p.errors...)
-			if p.Debug {
+			if s, ok := r.(stop); ok {
+				stopped = s.err
+			} else if p.Debug {
 				for _, err := range p.errors {
 					fmt.Println(err)
 				}
 				panic(r)
+			} else if _, ok := r.(bailout); !ok {
+				p.errors.Add(p.current.Begin, p.current, nil, fmt.Sprint(r))
+			}
+		}
+		p.errors.RemoveMultiples()
+		if stopped != nil {
+			p.stopped = true
+			pos := p.current.Begin
+			if pos.Line == 0 {
+				// nothing was read
+				pos = token.Position{Line: 1, Column: 1, File: p.file}
 			}
+			p.errors = append(p.errors, &Error{Pos: pos, Found: p.current, Msg: stopped.Error(), Err: stopped})
 		}
+		errors = p.errors
 	}()
+	if p.MaxSize > 0 && len(p.input) > p.MaxSize {
+		p.stop(ErrTooLarge)
+	}
 	// expecting either token.HTML or token.PHPBegin
 	nodes = make([]ast.Node, 0, 1)
 TokenLoop:
@@ -66,14 +136,13 @@ TokenLoop:
 			}
 		}
 	}
-	errors = p.errors
-	return nodes, errors
+	return nodes, nil
 }
 
 func (p *Parser) parseNode() ast.Node {
 	switch p.current.Typ {
 	case token.HTML:
-		return ast.Echo(&ast.Literal{Type: ast.String, Value: p.current.Val})
+		return p.parseHTML()
 	case token.PHPBegin:
 		return nil
 	case token.PHPEnd:
@@ -82,12 +151,80 @@ func (p *Parser) parseNode() ast.Node {
 	return p.parseStmt()
 }
 
+// parseHTML returns the inline HTML at the current token as an echo statement.
+func (p *Parser) parseHTML() *ast.EchoStmt {
+	lit := &ast.Literal{Type: ast.String, Value: p.current.Val}
+	p.setSpan(lit, p.current.Begin)
+	echo := ast.Echo(lit)
+	p.setSpan(echo, p.current.Begin)
+	return echo
+}
+
+// ParseFile parses the input like Parse, but returns it as a file that also
+// holds every token of the source, so that it can be printed back unchanged.
+// When the lexer fails, the rest of the source is kept in the Error token it
+// stopped at.
+func (p *Parser) ParseFile() (*ast.File, ErrorList) {
+	p.lossless = true
+	nodes, errors := p.Parse()
+	// parsing can stop short of the end of the input after an error, but
+	// the rest is not read when a limit stopped it
+	if n := len(p.tokens); !p.stopped && (n == 0 || p.tokens[n-1].Typ != token.EOF) {
+		for p.read().Typ != token.EOF {
+		}
+	}
+	p.keepUnlexed()
+	return ast.NewFile(nodes, p.tokens), errors
+}
+
+// keepUnlexed makes the error item the lexer gave up with, if it did, hold
+// the rest of the input, which was never lexed, so that the tokens of a file
+// still cover all of its source.
+func (p *Parser) keepUnlexed() {
+	n := len(p.tokens)
+	if n < 2 || p.tokens[n-1].Typ != token.EOF || p.tokens[n-2].Typ != token.Error {
+		return
+	}
+	t := &p.tokens[n-2]
+	t.Val = p.input[t.Begin.Position:]
+	t.End = t.Begin
+	t.End.Position += len(t.Val)
+	if i := strings.LastIndex(t.Val, "\n"); i >= 0 {
+		t.End.Line += strings.Count(t.Val, "\n")
+		t.End.Column = 1 + utf8.RuneCountInString(t.Val[i+1:])
+	} else {
+		t.End.Column += utf8.RuneCountInString(t.Val)
+	}
+	p.tokens[n-1].Begin, p.tokens[n-1].End = t.End, t.End
+}
+
 func (p *Parser) next() {
 	p.idx += 1
-	if len(p.previous) <= p.idx {
-		p.current = p.lexer.Next()
-		if p.PrintTokens {
-			fmt.Println(p.current)
+	if n := len(p.previous); n <= p.idx && n > 0 && p.previous[n-1].Typ == token.EOF {
+		// there is nothing to read past the end of the input
+		p.current = p.previous[n-1]
+		p.previous = append(p.previous, p.current)
+	} else if n <= p.idx {
+		select {
+		case <-p.done:
+			p.stop(p.ctx.Err())
+		default:
+		}
+		if p.MaxTokens > 0 && n >= p.MaxTokens {
+			p.stop(ErrTooManyTokens)
+		}
+		p.current = p.read()
+		for p.current.Typ == token.Comment || p.current.Typ == token.Space {
+			if p.current.Typ == token.Comment && isDocComment(p.current.Val) {
+				p.docComments[p.idx] = p.current.Val
+			}
+			p.current = p.read()
+		}
+		if p.current.Typ == token.Error {
+			// the lexer gives up at an error, which is reported as the
+			// end of the input
+			p.error(nil, p.current.Val)
+			p.current = token.Item{Typ: token.EOF, Begin: p.current.Begin, End: p.current.Begin}
 		}
 		p.previous = append(p.previous, p.current)
 	} else {
@@ -95,6 +232,29 @@ func (p *Parser) next() {
 	}
 }
 
+// read returns the next item from the lexer, keeping it for ParseFile if the
+// parser is lossless.
+func (p *Parser) read() token.Item {
+	i := p.lexer.Next()
+	if p.PrintTokens {
+		fmt.Println(i)
+	}
+	if p.lossless {
+		p.tokens = append(p.tokens, i)
+	}
+	return i
+}
+
+// isDocComment reports whether a comment is a /** */ style docblock.
+func isDocComment(comment string) bool {
+	return strings.HasPrefix(comment, "/**") && comment != "/**/"
+}
+
+// docComment returns the doc comment immediately preceding the current token.
+func (p *Parser) docComment() string {
+	return p.docComments[p.idx]
+}
+
 func (p *Parser) backup() {
 	p.idx -= 1
 	p.current = p.previous[p.idx]
@@ -117,13 +277,8 @@ func (p *Parser) expectCurrent(i ...token.Token) {
 }
 
 func (p *Parser) expectAndNext(i ...token.Token) {
-	defer p.next()
-	for _, Typ := range i {
-		if p.current.Typ == Typ {
-			return
-		}
-	}
-	p.expected(i...)
+	p.expectCurrent(i...)
+	p.next()
 }
 
 func (p *Parser) expect(i ...token.Token) {
@@ -131,8 +286,22 @@ func (p *Parser) expect(i ...token.Token) {
 	p.expectCurrent(i...)
 }
 
+// expected records that the current token is not one of i, and abandons the
+// statement or class member being parsed.
 func (p *Parser) expected(i ...token.Token) {
-	p.errorf("Found %s, expected %s", p.current, i)
+	p.error(i, fmt.Sprintf("Found %s, expected %s", p.current, i))
+	panic(bailout{})
+}
+
+// expectBlockEnd checks that the current token is one of end, closing a
+// block. A block left open at the end of the input is reported without
+// abandoning it, so that the tree of a file being edited keeps its shape.
+func (p *Parser) expectBlockEnd(end ...token.Token) {
+	if p.current.Typ == token.EOF {
+		p.error(end, fmt.Sprintf("Found %s, expected %s", p.current, end))
+		return
+	}
+	p.expectCurrent(end...)
 }
 
 func (p *Parser) accept(i ...token.Token) bool {
@@ -147,20 +316,62 @@ func (p *Parser) accept(i ...token.Token) bool {
 }
 
 func (p *Parser) errorf(str string, args ...interface{}) {
-	if p.errorCount > p.MaxErrors {
-		panic("too many errors")
+	p.error(nil, fmt.Sprintf(str, args...))
+}
+
+// error records an error at the current token, which was found instead of
+// one of expected, if there are any. Errors past MaxErrors are dropped.
+func (p *Parser) error(expected []token.Token, msg string) {
+	if p.MaxErrors > 0 && p.errorCount >= p.MaxErrors {
+		return
 	}
 	if _, ok := p.errorMap[p.current.Begin.Line]; ok {
 		return
 	}
-	errString := fmt.Sprintf(str, args...)
 	p.errorCount += 1
-	p.errors = append(p.errors, fmt.Errorf("%s: %s", p.errorPrefix(), errString))
+	p.errors.Add(p.current.Begin, p.current, expected, msg)
 	p.errorMap[p.current.Begin.Line] = true
 }
 
-func (p *Parser) errorPrefix() string {
-	return fmt.Sprintf("%d", p.current.Begin.Line)
+// spanner is satisfied by every node through its embedded ast.Span.
+type spanner interface {
+	SetSpan(begin, end token.Position)
+}
+
+// setSpan records n as running from begin through the end of the current
+// token.
+func (p *Parser) setSpan(n spanner, begin token.Position) {
+	n.SetSpan(begin, p.current.End)
+}
+
+// setSpanBefore records n as running from begin through the end of the token
+// preceding the current one, for nodes whose parsing stops on the token that
+// follows them.
+func (p *Parser) setSpanBefore(n spanner, begin token.Position) {
+	end := begin
+	if p.idx > 0 && p.previous[p.idx-1].End.Position > begin.Position {
+		end = p.previous[p.idx-1].End
+	}
+	n.SetSpan(begin, end)
+}
+
+// nodePos returns the start of n, or fallback if n is missing because of a
+// syntax error.
+func nodePos(n ast.Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+	return n.Pos()
+}
+
+// newVariable returns a variable named by the current token. The variable
+// spans from begin, usually the position of its $ operator, and its name
+// spans the current token.
+func (p *Parser) newVariable(begin token.Position) *ast.Variable {
+	v := ast.NewVariable(p.current.Val)
+	p.setSpan(v.Name.(*ast.Identifier), p.current.Begin)
+	p.setSpan(v, begin)
+	return v
 }
 
 func (p *Parser) parseNextExpression() ast.Expression {
@@ -0,0 +1,551 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+// parseSource parses src after an opening tag, reporting any errors and
+// returning nil if there are some.
+func parseSource(t *testing.T, src string) []ast.Node {
+	t.Helper()
+	nodes, errs := NewParser("<?php " + src).Parse()
+	if len(errs) != 0 {
+		t.Errorf("%q: unexpected errors: %v", src, errs)
+		return nil
+	}
+	return nodes
+}
+
+type shapeTest struct {
+	src, want string
+}
+
+// testShapes parses the source of each test and compares the shape of the
+// node pick returns with the one wanted. When pick is nil, the shapes of all
+// the top level nodes are compared, separated by "; ".
+func testShapes(t *testing.T, tests []shapeTest, pick func([]ast.Node) ast.Node) {
+	t.Helper()
+	for _, test := range tests {
+		nodes := parseSource(t, test.src)
+		if nodes == nil {
+			continue
+		}
+		var got string
+		if pick != nil {
+			got = shape(pick(nodes))
+		} else {
+			got = shapes(nodes, "; ")
+		}
+		if got != test.want {
+			t.Errorf("%q: got %s, want %s", test.src, got, test.want)
+		}
+	}
+}
+
+// testErrors checks that each of sources fails to parse after an opening
+// tag.
+func testErrors(t *testing.T, sources ...string) {
+	t.Helper()
+	for _, src := range sources {
+		if _, errs := NewParser("<?php " + src).Parse(); len(errs) == 0 {
+			t.Errorf("%q: no errors", src)
+		}
+	}
+}
+
+// typeName returns the name of the type of n without its package.
+func typeName(n ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+// shapes joins the shapes of the elements of list, which must be a slice,
+// with sep.
+func shapes(list interface{}, sep string) string {
+	v := reflect.ValueOf(list)
+	s := make([]string, v.Len())
+	for i := range s {
+		s[i] = shape(v.Index(i).Interface())
+	}
+	return strings.Join(s, sep)
+}
+
+// shape renders a node compactly, with every operation parenthesized, so
+// that tests can compare what was parsed and how operators were grouped.
+// Declarations are rendered without their bodies, except for the members of
+// classes, interfaces and traits.
+func shape(n interface{}) string {
+	switch n := n.(type) {
+	case nil:
+		return "_"
+	case *ast.ExpressionStmt:
+		return shape(n.Expression)
+	case *ast.BinaryExpression:
+		return fmt.Sprintf("(%s %s %s)", shape(n.Antecedent), n.Operator, shape(n.Subsequent))
+	case *ast.AssignmentExpression:
+		return fmt.Sprintf("(%s %s %s)", shape(n.Assignee), n.Operator, shape(n.Value))
+	case *ast.TernaryExpression:
+		return fmt.Sprintf("(%s ? %s : %s)", shape(n.Condition), shape(n.True), shape(n.False))
+	case *ast.UnaryExpression:
+		if n.Preceding {
+			return fmt.Sprintf("(%s%s)", shape(n.Operand), n.Operator)
+		}
+		return fmt.Sprintf("(%s%s)", n.Operator, shape(n.Operand))
+	case *ast.Variable:
+		return "$" + shape(n.Name)
+	case *ast.Identifier:
+		return n.Value
+	case *ast.Literal:
+		return n.Value
+	case *ast.BadExpr:
+		return "BadExpr"
+	case *ast.FunctionCallExpression:
+		return fmt.Sprintf("%s(%s)", shape(n.FunctionName), shapes(n.Arguments, ", "))
+	case *ast.MethodCallExpression:
+		return fmt.Sprintf("%s->%s", shape(n.Receiver), shape(n.FunctionCallExpression))
+	case *ast.NewExpression:
+		if n.Arguments == nil {
+			// the arguments are usually parsed as a call of the class
+			return "new " + shape(n.Class)
+		}
+		return fmt.Sprintf("new %s(%s)", shape(n.Class), shapes(n.Arguments, ", "))
+	case *ast.CallArgument:
+		if n.Spread {
+			return "..." + shape(n.Value)
+		}
+		return fmt.Sprintf("%s: %s", n.Name, shape(n.Value))
+	case *ast.PropertyExpression:
+		return fmt.Sprintf("%s->%s", shape(n.Receiver), shape(n.Name))
+	case *ast.ClassExpression:
+		return fmt.Sprintf("%s::%s", shape(n.Receiver), shape(n.Expression))
+	case *ast.ConstantExpression:
+		return shape(n.Variable.Name)
+	case *ast.Include:
+		return fmt.Sprintf("%s %s", n.Operator, shape(n.Expressions[0]))
+	case *ast.YieldExpression:
+		if n.Key != nil {
+			return fmt.Sprintf("(yield %s => %s)", shape(n.Key), shape(n.Value))
+		}
+		if n.Value != nil {
+			return fmt.Sprintf("(yield %s)", shape(n.Value))
+		}
+		return "(yield)"
+	case *ast.YieldFromExpression:
+		return fmt.Sprintf("(yield from %s)", shape(n.Source))
+	case *ast.ListStatement:
+		return fmt.Sprintf("(list(%s) %s %s)", shapes(n.Assignees, ", "), n.Operator, shape(n.Value))
+	case *ast.AnonymousFunction:
+		s := "function "
+		if n.ByRef {
+			s += "&"
+		}
+		s += "(" + shapes(n.Arguments, ", ") + ")"
+		if len(n.ClosureVariables) > 0 {
+			s += " use (" + shapes(n.ClosureVariables, ", ") + ")"
+		}
+		return s + returnShape(n.ReturnType)
+
+	case *ast.FunctionStmt:
+		return shape(n.FunctionDefinition)
+	case *ast.FunctionDefinition:
+		s := "function "
+		if n.ByRef {
+			s += "&"
+		}
+		return s + n.Name + "(" + shapes(n.Arguments, ", ") + ")" + returnShape(n.ReturnType)
+	case *ast.FunctionArgument:
+		s := ""
+		if n.TypeHint != nil {
+			s += shape(n.TypeHint) + " "
+		}
+		if n.ByRef {
+			s += "&"
+		}
+		if n.Variadic {
+			s += "..."
+		}
+		s += shape(n.Variable)
+		if n.Default != nil {
+			s += " = " + shape(n.Default)
+		}
+		return s
+	case *ast.TypeExpr:
+		if n == nil {
+			return "_"
+		}
+		if n.Form == ast.NamedType {
+			return n.Name
+		}
+		return n.Form.String() + "(" + shapes(n.Types, ", ") + ")"
+	case *ast.TryStmt:
+		s := fmt.Sprintf("try[%d]", len(n.TryBlock.Statements))
+		for _, c := range n.CatchStmts {
+			s += " catch(" + strings.Join(c.CatchType, "|")
+			if c.CatchVar != nil {
+				s += " " + shape(c.CatchVar)
+			}
+			s += fmt.Sprintf(")[%d]", len(c.CatchBlock.Statements))
+		}
+		if n.FinallyBlock != nil {
+			s += fmt.Sprintf(" finally[%d]", len(n.FinallyBlock.Statements))
+		}
+		return s
+
+	case *ast.Class:
+		s := ""
+		if n.Abstract {
+			s += "abstract "
+		}
+		if n.Final {
+			s += "final "
+		}
+		s += "class " + n.Name
+		if n.Extends != "" {
+			s += " extends " + n.Extends
+		}
+		if len(n.Implements) > 0 {
+			s += " implements " + strings.Join(n.Implements, ", ")
+		}
+		return s + members(n.TraitUses, n.Constants, n.Properties, n.Methods)
+	case *ast.Interface:
+		s := "interface " + n.Name
+		if len(n.Inherits) > 0 {
+			s += " extends " + strings.Join(n.Inherits, ", ")
+		}
+		return s + members(n.Constants, n.Methods)
+	case *ast.Trait:
+		return "trait " + n.Name + members(n.TraitUses, n.Constants, n.Properties, n.Methods)
+	case *ast.TraitUse:
+		s := "use " + strings.Join(n.Traits, ", ")
+		if n.Rules != nil {
+			s += " {" + shapes(n.Rules, "; ") + "}"
+		}
+		return s
+	case *ast.TraitRule:
+		s := n.Method
+		if n.Trait != "" {
+			s = n.Trait + "::" + s
+		}
+		if n.InsteadOf != nil {
+			return s + " insteadof " + strings.Join(n.InsteadOf, ", ")
+		}
+		s += " as"
+		if n.ChangesVisibility {
+			s += " " + n.Visibility.String()
+		}
+		if n.Alias != "" {
+			s += " " + n.Alias
+		}
+		return s
+	case *ast.Constant:
+		return fmt.Sprintf("const %s = %s", shape(n.Variable.Name), shape(n.Value))
+	case *ast.Property:
+		s := n.Visibility.String()
+		if n.Static {
+			s += " static"
+		}
+		if n.TypeHint != nil {
+			s += " " + shape(n.TypeHint)
+		}
+		s += " " + n.Name
+		if n.Initialization != nil {
+			s += " = " + shape(n.Initialization)
+		}
+		return s
+	case *ast.Method:
+		s := ""
+		if n.Final {
+			s += "final "
+		}
+		if n.Abstract {
+			s += "abstract "
+		}
+		s += n.Visibility.String() + " "
+		if n.Static {
+			s += "static "
+		}
+		return s + shape(n.FunctionStmt)
+
+	case *ast.NamespaceStmt:
+		s := "namespace"
+		if n.Name != "" {
+			s += " " + n.Name
+		}
+		if n.Block != nil {
+			s += " {" + shapes(n.Block.Statements, "; ") + "}"
+		}
+		return s
+	case *ast.UseStmt:
+		s := "use "
+		if n.Type != ast.UseClass {
+			s += n.Type.String() + " "
+		}
+		uses := make([]string, len(n.Uses))
+		for i, u := range n.Uses {
+			if u.Type != n.Type {
+				uses[i] = u.Type.String() + " "
+			}
+			uses[i] += u.Name
+			if u.Alias != "" {
+				uses[i] += " as " + u.Alias
+			}
+		}
+		if n.Prefix != "" {
+			return s + n.Prefix + "\\{" + strings.Join(uses, ", ") + "}"
+		}
+		return s + strings.Join(uses, ", ")
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+// returnShape renders the return type of a function after a colon, if it
+// has one.
+func returnShape(t *ast.TypeExpr) string {
+	if t == nil {
+		return ""
+	}
+	return ": " + shape(t)
+}
+
+// members renders the members of a class, interface or trait in braces,
+// taking the lists of each kind in turn.
+func members(lists ...interface{}) string {
+	var s []string
+	for _, list := range lists {
+		if v := shapes(list, "; "); v != "" {
+			s = append(s, v)
+		}
+	}
+	return " {" + strings.Join(s, "; ") + "}"
+}
+
+func TestNodePositions(t *testing.T) {
+	tests := []struct {
+		src        string
+		index      int // of the node checked
+		begin, end string
+	}{
+		{"<?php foo();", 0, "a.php:1:7", "a.php:1:13"},
+		{"<?php\n\nfoo();\nbar();", 1, "a.php:4:1", "a.php:4:7"},
+		{"<?php $é = 'ü'; foo();", 1, "a.php:1:17", "a.php:1:23"},
+		{"<?php '日本'.'ü'; foo();", 0, "a.php:1:7", "a.php:1:16"},
+		{"<html>\n<?php foo();", 0, "a.php:1:1", "a.php:2:1"},
+	}
+	for _, test := range tests {
+		nodes, errs := NewParserFile("a.php", test.src).Parse()
+		if len(errs) != 0 {
+			t.Errorf("%q: unexpected errors: %v", test.src, errs)
+			continue
+		}
+		n := nodes[test.index]
+		if begin, end := n.Pos().String(), n.End().String(); begin != test.begin || end != test.end {
+			t.Errorf("%q: node %d spans %s to %s, want %s to %s", test.src, test.index, begin, end, test.begin, test.end)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"<?php foo(;", "a.php:1:11"},
+		{"<?php\n$é = ;", "a.php:2:6"},
+		{"<?php\n'日本' 1;", "a.php:2:6"},
+	}
+	for _, test := range tests {
+		_, errs := NewParserFile("a.php", test.src).Parse()
+		if len(errs) == 0 {
+			t.Errorf("%q: no errors", test.src)
+			continue
+		}
+		if got := errs[0].Pos.String(); got != test.want {
+			t.Errorf("%q: error at %s, want %s", test.src, got, test.want)
+		}
+	}
+}
+
+// badSpans lists the source of each ast.BadStmt and ast.BadExpr in nodes.
+func badSpans(src string, nodes []ast.Node) []string {
+	var spans []string
+	for _, n := range nodes {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.BadStmt, *ast.BadExpr:
+				spans = append(spans, src[n.Pos().Position:n.End().Position])
+			}
+			return true
+		})
+	}
+	return spans
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		src   string
+		nodes string // the types of the top level nodes
+		bad   string // the source of the bad nodes, separated by |
+		err   string
+	}{
+		{"<?php $a = ; b();", "ExpressionStmt ExpressionStmt", "", "1:12"},
+		{"<?php a(; b();", "BadStmt ExpressionStmt", "a(;", "1:9"},
+		{"<?php function f() { a(; b(); } c();", "FunctionStmt ExpressionStmt", "a(;", "1:24"},
+		{"<?php if ($a { b(); } c();", "BadStmt ExpressionStmt", "if ($a { b(); }", "1:19"},
+		{"<?php a() b(); c();", "BadStmt ExpressionStmt", "a() b();", "1:11"},
+		{"<?php function f() { a();", "FunctionStmt", "", "1:26"},
+	}
+	for _, test := range tests {
+		nodes, errs := NewParser(test.src).Parse()
+		var types []string
+		for _, n := range nodes {
+			types = append(types, typeName(n))
+		}
+		if got := strings.Join(types, " "); got != test.nodes {
+			t.Errorf("%q: got nodes %s, want %s", test.src, got, test.nodes)
+		}
+		if got := strings.Join(badSpans(test.src, nodes), "|"); got != test.bad {
+			t.Errorf("%q: got bad nodes %q, want %q", test.src, got, test.bad)
+		}
+		if len(errs) != 1 || errs[0].Pos.String() != test.err {
+			t.Errorf("%q: got errors %v, want one at %s", test.src, errs, test.err)
+		}
+	}
+}
+
+func TestRecoverMember(t *testing.T) {
+	nodes, errs := NewParser("<?php class A { public function f( ; public $p; function g() {} }").Parse()
+	if len(errs) != 1 {
+		t.Errorf("got errors %v, want one", errs)
+	}
+	if got, want := shape(nodes[0]), "class A {public $p; public function g()}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	src := "<?php " + strings.Repeat("a(;\n", 12) + "b();"
+	p := NewParser(src)
+	nodes, errs := p.Parse()
+	if len(errs) != 10 {
+		t.Errorf("got %d errors, want 10", len(errs))
+	}
+	if len(nodes) != 13 {
+		t.Fatalf("got %d nodes, want 13", len(nodes))
+	}
+	if _, ok := nodes[12].(*ast.ExpressionStmt); !ok {
+		t.Errorf("last node is %T, want *ast.ExpressionStmt", nodes[12])
+	}
+
+	p = NewParser(src)
+	p.MaxErrors = 0
+	if _, errs := p.Parse(); len(errs) != 12 {
+		t.Errorf("without a limit: got %d errors, want 12", len(errs))
+	}
+}
+
+func TestLimits(t *testing.T) {
+	deep := "<?php a(); " + strings.Repeat("(", 2000) + "1" + strings.Repeat(")", 2000) + ";"
+	tests := []struct {
+		name  string
+		src   string
+		set   func(*Parser)
+		err   error // wrapped by the last error
+		nodes int   // the number of nodes parsed before stopping
+	}{
+		{"size", "<?php a(); b();", func(p *Parser) { p.MaxSize = 10 }, ErrTooLarge, 0},
+		{"size within limit", "<?php a(); b();", func(p *Parser) { p.MaxSize = 15 }, nil, 2},
+		{"tokens", "<?php a(); b(); c();", func(p *Parser) { p.MaxTokens = 8 }, ErrTooManyTokens, 1},
+		{"tokens within limit", "<?php a(); b(); c();", func(p *Parser) { p.MaxTokens = 100 }, nil, 3},
+		{"depth", deep, func(p *Parser) {}, ErrTooDeep, 1},
+		{"depth without limit", deep, func(p *Parser) { p.MaxDepth = 0 }, nil, 2},
+		{"nested blocks", "<?php " + strings.Repeat("{", 20) + strings.Repeat("}", 20), func(p *Parser) { p.MaxDepth = 10 }, ErrTooDeep, 0},
+	}
+	for _, test := range tests {
+		p := NewParser(test.src)
+		test.set(p)
+		nodes, errs := p.Parse()
+		if len(nodes) != test.nodes {
+			t.Errorf("%s: got %d nodes, want %d", test.name, len(nodes), test.nodes)
+		}
+		if test.err == nil {
+			if len(errs) != 0 {
+				t.Errorf("%s: unexpected errors: %v", test.name, errs)
+			}
+			continue
+		}
+		if len(errs) == 0 {
+			t.Errorf("%s: no errors, want %v", test.name, test.err)
+			continue
+		}
+		if last := errs[len(errs)-1]; !errors.Is(last, test.err) {
+			t.Errorf("%s: last error is %v, want %v", test.name, last, test.err)
+		}
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nodes, errs := NewParser("<?php a(); b();").ParseContext(ctx)
+	if len(nodes) != 0 {
+		t.Errorf("got %d nodes from a cancelled parse, want 0", len(nodes))
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Fatalf("got errors %v, want %v", errs, context.Canceled)
+	}
+	if errs[0].Pos.String() != "1:1" {
+		t.Errorf("error at %s, want 1:1", errs[0].Pos)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, errs = NewParser("<?php a(;").ParseContext(ctx)
+	if len(errs) == 0 || !errors.Is(errs[len(errs)-1], context.DeadlineExceeded) {
+		t.Errorf("got errors %v, want %v last", errs, context.DeadlineExceeded)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	class := func(nodes []ast.Node) *ast.Class { return nodes[0].(*ast.Class) }
+	tests := []struct {
+		src  string
+		doc  func([]ast.Node) string
+		want string
+	}{
+		{"/** F. */ function f() {}", func(n []ast.Node) string { return n[0].(*ast.FunctionStmt).Doc }, "/** F. */"},
+		{"/** A. */ class A {}", func(n []ast.Node) string { return class(n).Doc }, "/** A. */"},
+		{"/** A. */ abstract class A {}", func(n []ast.Node) string { return class(n).Doc }, "/** A. */"},
+		{"/** I. */ interface I {}", func(n []ast.Node) string { return n[0].(*ast.Interface).Doc }, "/** I. */"},
+		{"class A { /** M. */ public function m() {} }", func(n []ast.Node) string { return class(n).Methods[0].Doc }, "/** M. */"},
+		{"class A { /** M. */ abstract function m(); }", func(n []ast.Node) string { return class(n).Methods[0].Doc }, "/** M. */"},
+		{"interface I { /** M. */ function m(); }", func(n []ast.Node) string { return n[0].(*ast.Interface).Methods[0].Doc }, "/** M. */"},
+		{"class A { /** @var int */ private $p; }", func(n []ast.Node) string { return class(n).Properties[0].Doc }, "/** @var int */"},
+		{"class A { /** C. */ const C = 1; }", func(n []ast.Node) string { return class(n).Constants[0].Doc }, "/** C. */"},
+		{"/**\n * F.\n * @return int\n */\nfunction f() {}", func(n []ast.Node) string { return n[0].(*ast.FunctionStmt).Doc }, "/**\n * F.\n * @return int\n */"},
+		{"/** Old. */ /** F. */ function f() {}", func(n []ast.Node) string { return n[0].(*ast.FunctionStmt).Doc }, "/** F. */"},
+
+		// only docblocks attach
+		{"// F.\nfunction f() {}", func(n []ast.Node) string { return n[0].(*ast.FunctionStmt).Doc }, ""},
+		{"/* A. */ class A {}", func(n []ast.Node) string { return class(n).Doc }, ""},
+		{"/**/ class A {}", func(n []ast.Node) string { return class(n).Doc }, ""},
+		{"class A { # M.\n public function m() {} }", func(n []ast.Node) string { return class(n).Methods[0].Doc }, ""},
+		{"class A { /* P. */ private $p; }", func(n []ast.Node) string { return class(n).Properties[0].Doc }, ""},
+		{"/** X. */ $x = 1; function f() {}", func(n []ast.Node) string { return n[1].(*ast.FunctionStmt).Doc }, ""},
+		{"class A { /** P. */ private $p; public function m() {} }", func(n []ast.Node) string { return class(n).Methods[0].Doc }, ""},
+	}
+	for _, test := range tests {
+		nodes := parseSource(t, test.src)
+		if nodes == nil {
+			continue
+		}
+		if got := test.doc(nodes); got != test.want {
+			t.Errorf("%q: got doc %q, want %q", test.src, got, test.want)
+		}
+	}
+}
@@ -5,7 +5,26 @@ import (
 	"github.com/jxwr/php-parser/token"
 )
 
-func (p *Parser) parseStmt() ast.Statement {
+// parseStmt parses the statement starting at the current token, leaving
+// the parser on its last token. A statement with a syntax error is returned
+// as an ast.BadStmt spanning the tokens skipped to recover from it.
+func (p *Parser) parseStmt() (stmt ast.Statement) {
+	begin, start := p.current.Begin, p.idx
+	p.nest()
+	defer p.unnest()
+	defer func() {
+		if r := recover(); r != nil {
+			p.skip(r, start, stmtStart)
+			bad := &ast.BadStmt{}
+			p.setSpan(bad, begin)
+			stmt = bad
+		}
+	}()
+	return p.parseSimpleStmt()
+}
+
+func (p *Parser) parseSimpleStmt() ast.Statement {
+	begin := p.current.Begin
 	switch p.current.Typ {
 	case token.BlockBegin:
 		p.backup()
@@ -29,43 +48,32 @@ func (p *Parser) parseStmt() ast.Statement {
 			p.next()
 		}
 		p.expectStmtEnd()
+		p.setSpan(g, begin)
 		return g
 	case token.Namespace:
-		p.expect(token.Identifier)
-		p.expectStmtEnd()
-		// We are ignoring this for now
-		return nil
+		return p.parseNamespace()
 	case token.Use:
-		p.expect(token.Identifier)
-		if p.peek().Typ == token.AsOperator {
-			p.expect(token.AsOperator)
-			p.expect(token.Identifier)
-		}
-		p.expectStmtEnd()
-		// We are ignoring this for now
-		return nil
+		return p.parseUse()
 	case token.Static:
 		if p.peek().Typ == token.ScopeResolutionOperator {
 			expr := p.parseExpression()
 			p.expectStmtEnd()
-			stmt := &ast.ExpressionStmt{expr}
+			stmt := &ast.ExpressionStmt{Expression: expr}
+			p.setSpan(stmt, begin)
 			return stmt
 		}
 		s := &ast.StaticVariableDeclaration{Declarations: make([]ast.Expression, 0)}
 		for {
 			p.expect(token.VariableOperator)
+			varBegin := p.current.Begin
 			p.expect(token.Identifier)
-			v := ast.NewVariable(p.current.Val)
+			v := p.newVariable(varBegin)
 			if p.peek().Typ == token.AssignmentOperator {
 				p.expect(token.AssignmentOperator)
-				op := p.current.Val
-				p.expect(token.Null, token.StringLiteral, token.BooleanLiteral, token.NumberLiteral, token.Array)
-				switch p.current.Typ {
-				case token.Array:
-					s.Declarations = append(s.Declarations, &ast.AssignmentExpression{Assignee: v, Value: p.parseArrayDeclaration(), Operator: op})
-				default:
-					s.Declarations = append(s.Declarations, &ast.AssignmentExpression{Assignee: v, Value: p.parseLiteral(), Operator: op})
-				}
+				assign := &ast.AssignmentExpression{Assignee: v, Operator: p.current.Val}
+				assign.Value = p.parseNextExpression()
+				p.setSpan(assign, varBegin)
+				s.Declarations = append(s.Declarations, assign)
 			}
 			s.Declarations = append(s.Declarations, v)
 			if p.peek().Typ != token.Comma {
@@ -74,10 +82,12 @@ func (p *Parser) parseStmt() ast.Statement {
 			p.next()
 		}
 		p.expectStmtEnd()
+		p.setSpan(s, begin)
 		return s
 	case token.VariableOperator, token.UnaryOperator:
-		expr := &ast.ExpressionStmt{p.parseExpression()}
+		expr := &ast.ExpressionStmt{Expression: p.parseExpression()}
 		p.expectStmtEnd()
+		p.setSpan(expr, begin)
 		return expr
 	case token.Print:
 		requireParen := false
@@ -90,6 +100,7 @@ func (p *Parser) parseStmt() ast.Statement {
 			p.expect(token.CloseParen)
 		}
 		p.expectStmtEnd()
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.Function:
 		return p.parseFunctionStmt()
@@ -99,7 +110,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		}
 		var expr ast.Statement
 		if p.accept(token.HTML) {
-			expr = ast.Echo(&ast.Literal{Type: ast.String, Value: p.current.Val})
+			expr = p.parseHTML()
 		}
 		p.next()
 		if p.current.Typ != token.EOF {
@@ -116,6 +127,7 @@ func (p *Parser) parseStmt() ast.Statement {
 		}
 		p.expectStmtEnd()
 		echo := ast.Echo(exprs...)
+		p.setSpan(echo, begin)
 		return echo
 	case token.If:
 		return p.parseIf()
@@ -133,6 +145,8 @@ func (p *Parser) parseStmt() ast.Statement {
 		return p.parseClass()
 	case token.Interface:
 		return p.parseInterface()
+	case token.Trait:
+		return p.parseTrait()
 	case token.Return:
 		p.next()
 		stmt := &ast.ReturnStmt{}
@@ -140,6 +154,7 @@ func (p *Parser) parseStmt() ast.Statement {
 			stmt.Expression = p.parseExpression()
 			p.expectStmtEnd()
 		}
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.Break:
 		p.next()
@@ -148,6 +163,7 @@ func (p *Parser) parseStmt() ast.Statement {
 			stmt.Expression = p.parseExpression()
 			p.expectStmtEnd()
 		}
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.Continue:
 		p.next()
@@ -156,10 +172,12 @@ func (p *Parser) parseStmt() ast.Statement {
 			stmt.Expression = p.parseExpression()
 			p.expectStmtEnd()
 		}
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.Throw:
 		stmt := &ast.ThrowStmt{Expression: p.parseNextExpression()}
 		p.expectStmtEnd()
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.Exit:
 		stmt := &ast.ExitStmt{}
@@ -171,43 +189,27 @@ func (p *Parser) parseStmt() ast.Statement {
 			p.expect(token.CloseParen)
 		}
 		p.expectStmtEnd()
+		p.setSpan(stmt, begin)
 		return stmt
 	case token.List:
-		return p.parseList()
+		l := p.parseList()
+		p.expectStmtEnd()
+		p.setSpan(l, begin)
+		return l
 	case token.Try:
-		stmt := &ast.TryStmt{}
-		stmt.TryBlock = p.parseBlock()
-		for p.expect(token.Catch); p.current.Typ == token.Catch; p.next() {
-			caught := &ast.CatchStmt{}
-			p.expect(token.OpenParen)
-			p.expect(token.Identifier)
-			caught.CatchType = p.current.Val
-			p.expect(token.VariableOperator)
-			p.expect(token.Identifier)
-			caught.CatchVar = ast.NewVariable(p.current.Val)
-			p.expect(token.CloseParen)
-			caught.CatchBlock = p.parseBlock()
-			stmt.CatchStmts = append(stmt.CatchStmts, caught)
-		}
-		p.backup()
-		return stmt
-	case token.IgnoreErrorOperator:
-		// Ignore this operator
-		p.next()
-		return p.parseStmt()
+		return p.parseTry()
 	case token.StatementEnd:
 		// this is an empty statement
-		return &ast.EmptyStatement{}
+		stmt := &ast.EmptyStatement{}
+		p.setSpan(stmt, begin)
+		return stmt
 	case token.Declare:
 		return p.parseDeclareBlock()
 	default:
-		expr := p.parseExpression()
-		if expr != nil {
-			p.expectStmtEnd()
-			return &ast.ExpressionStmt{expr}
-		}
-		p.errorf("Found %s, statement or expression", p.current)
-		return nil
+		stmt := &ast.ExpressionStmt{Expression: p.parseExpression()}
+		p.expectStmtEnd()
+		p.setSpan(stmt, begin)
+		return stmt
 	}
 }
 
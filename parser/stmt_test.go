@@ -0,0 +1,24 @@
+package parser
+
+import "testing"
+
+func TestTry(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"try { a(); } catch (E $e) { b(); }", "try[1] catch(E $e)[1]"},
+		{"try { a(); } finally { b(); c(); }", "try[1] finally[2]"},
+		{"try {} catch (A $a) {} catch (B $b) {} finally {}", "try[0] catch(A $a)[0] catch(B $b)[0] finally[0]"},
+		{"try {} catch (A | \\B\\C | D $e) {}", "try[0] catch(A|\\B\\C|D $e)[0]"},
+		{"try {} catch (E) { a(); }", "try[0] catch(E)[1]"},
+		{"try {} catch (A|B) {} finally { a(); }", "try[0] catch(A|B)[0] finally[1]"},
+	}, nil)
+}
+
+func TestTryErrors(t *testing.T) {
+	testErrors(t,
+		"try { a(); } b();",
+		"try {} catch ($e) {}",
+		"try {} catch (A| $e) {}",
+		"try {} catch (A $e {}",
+		"try {} finally",
+	)
+}
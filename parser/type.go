@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+// typeNameTokens lists the tokens that name a type. Most names, such as int
+// or mixed, are identifiers, but a few are keywords or literals.
+var typeNameTokens = map[token.Token]bool{
+	token.Identifier:     true,
+	token.Array:          true,
+	token.Self:           true,
+	token.Static:         true,
+	token.Parent:         true,
+	token.Null:           true,
+	token.BooleanLiteral: true,
+}
+
+// startsType reports whether a type declaration begins at the next token.
+func (p *Parser) startsType() bool {
+	switch t := p.peek().Typ; {
+	case typeNameTokens[t], t == token.TernaryOperator1, t == token.OpenParen:
+		return true
+	}
+	return false
+}
+
+// parseTypeExpr parses the type declaration beginning at the next token: a
+// type name, a nullable type, or a union or intersection of types. Within a
+// union, an intersection must be parenthesized.
+func (p *Parser) parseTypeExpr() *ast.TypeExpr {
+	if p.accept(token.TernaryOperator1) {
+		begin := p.current.Begin
+		t := &ast.TypeExpr{Form: ast.NullableType, Types: []*ast.TypeExpr{p.parseTypeName()}}
+		p.setSpan(t, begin)
+		return t
+	}
+	begin := p.peek().Begin
+	first := p.parseUnionMember()
+	if p.peek().Typ != token.BitwiseOrOperator {
+		return first
+	}
+	t := &ast.TypeExpr{Form: ast.UnionType, Types: []*ast.TypeExpr{first}}
+	for p.accept(token.BitwiseOrOperator) {
+		t.Types = append(t.Types, p.parseUnionMember())
+	}
+	p.setSpan(t, begin)
+	return t
+}
+
+// parseUnionMember parses a type name or an intersection, which is
+// parenthesized when it is a member of a union.
+func (p *Parser) parseUnionMember() *ast.TypeExpr {
+	if !p.accept(token.OpenParen) {
+		return p.parseIntersection()
+	}
+	t := p.parseIntersection()
+	p.expect(token.CloseParen)
+	return t
+}
+
+func (p *Parser) parseIntersection() *ast.TypeExpr {
+	first := p.parseTypeName()
+	if !p.intersects() {
+		return first
+	}
+	t := &ast.TypeExpr{Form: ast.IntersectionType, Types: []*ast.TypeExpr{first}}
+	for p.intersects() {
+		p.next()
+		t.Types = append(t.Types, p.parseTypeName())
+	}
+	p.setSpan(t, first.Pos())
+	return t
+}
+
+// intersects reports whether the next token is an & joining the types of an
+// intersection, rather than one marking a parameter passed by reference.
+func (p *Parser) intersects() bool {
+	if p.peek().Typ != token.AmpersandOperator {
+		return false
+	}
+	p.next()
+	defer p.backup()
+	return typeNameTokens[p.peek().Typ]
+}
+
+func (p *Parser) parseTypeName() *ast.TypeExpr {
+	p.next()
+	if !typeNameTokens[p.current.Typ] {
+		p.expected(token.Identifier)
+	}
+	t := &ast.TypeExpr{Form: ast.NamedType, Name: p.current.Val}
+	p.setSpan(t, p.current.Begin)
+	return t
+}
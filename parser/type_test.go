@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+func TestTypes(t *testing.T) {
+	types := []struct {
+		typ, want string
+	}{
+		{"int", "int"},
+		{"\\N\\Foo", "\\N\\Foo"},
+		{"?Foo", "nullable(Foo)"},
+		{"?array", "nullable(array)"},
+		{"int|string|null", "union(int, string, null)"},
+		{"A&B&C", "intersection(A, B, C)"},
+		{"(A&B)|null", "union(intersection(A, B), null)"},
+		{"false|(A&B)|C", "union(false, intersection(A, B), C)"},
+		{"self|static", "union(self, static)"},
+		{"callable", "callable"},
+		{"mixed", "mixed"},
+	}
+	var tests []shapeTest
+	for _, typ := range types {
+		tests = append(tests,
+			shapeTest{"function f(" + typ.typ + " $a) {}", "function f(" + typ.want + " $a)"},
+			shapeTest{"function f(): " + typ.typ + " {}", "function f(): " + typ.want},
+			shapeTest{"class A { public " + typ.typ + " $a; }", "class A {public " + typ.want + " $a}"},
+			shapeTest{"class A { var " + typ.typ + " $a; }", "class A {public " + typ.want + " $a}"},
+		)
+	}
+	testShapes(t, tests, nil)
+
+	for _, typ := range types {
+		src := "function f(" + typ.typ + " $a) {}"
+		nodes := parseSource(t, src)
+		if nodes == nil {
+			continue
+		}
+		if got := nodes[0].(*ast.FunctionStmt).Arguments[0].TypeHint.String(); got != typ.typ {
+			t.Errorf("%q: type prints as %s", src, got)
+		}
+	}
+}
+
+func TestUntyped(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"function f($a) {}", "function f($a)"},
+		{"class A { public $p; }", "class A {public $p}"},
+		{"class A { var $p = 1, $q; }", "class A {public $p = 1; public $q}"},
+		{"$g = function ($b) {};", "($g = function ($b))"},
+	}, nil)
+}
+
+func TestTypeAndReference(t *testing.T) {
+	testShapes(t, []shapeTest{
+		{"function f(A &$b) {}", "function f(A &$b)"},
+		{"function f(A & $b) {}", "function f(A &$b)"},
+		{"function f(A&B $c) {}", "function f(intersection(A, B) $c)"},
+		{"function f(A&B &$c) {}", "function f(intersection(A, B) &$c)"},
+		{"function f(?A &...$c) {}", "function f(nullable(A) &...$c)"},
+	}, nil)
+}
+
+func TestTypeErrors(t *testing.T) {
+	testErrors(t,
+		"function f(A| $a) {}",
+		"function f(A&|B $a) {}",
+		"function f(?A|B $a) {}",
+		"function f(): {}",
+		"function f(): ? {}",
+		"class A { public ?$a; }",
+		"class A { var ?$a; }",
+		"class A { var function f() {} }",
+	)
+}
@@ -0,0 +1,58 @@
+package phpdoc
+
+import "github.com/jxwr/php-parser/ast"
+
+// Function returns the parsed doc comment of a function or method.
+func Function(f *ast.FunctionStmt) *DocBlock {
+	return Parse(f.Doc)
+}
+
+// Property returns the parsed doc comment of a property.
+func Property(p *ast.Property) *DocBlock {
+	return Parse(p.Doc)
+}
+
+// Class returns the parsed doc comment of a class.
+func Class(c *ast.Class) *DocBlock {
+	return Parse(c.Doc)
+}
+
+// ParamType returns the documented type of the named argument, given without
+// $, or nil if it is undocumented.
+func (d *DocBlock) ParamType(name string) *Type {
+	if t := d.Param(name); t != nil {
+		return t.Type
+	}
+	return nil
+}
+
+// ReturnType returns the documented return type, or nil if it is
+// undocumented.
+func (d *DocBlock) ReturnType() *Type {
+	if t := d.Return(); t != nil {
+		return t.Type
+	}
+	return nil
+}
+
+// VarType returns the type documented by the @var tag, or nil if there is
+// none.
+func (d *DocBlock) VarType() *Type {
+	if t := d.Var(); t != nil {
+		return t.Type
+	}
+	return nil
+}
+
+// ArgumentTypes returns the documented type of each argument of f in order,
+// with nil entries for undocumented arguments.
+func ArgumentTypes(f *ast.FunctionStmt) []*Type {
+	doc := Function(f)
+	types := make([]*Type, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		if name, ok := arg.Variable.Name.(*ast.Identifier); ok {
+			types[i] = doc.ParamType(name.Value)
+		}
+	}
+	return types
+}
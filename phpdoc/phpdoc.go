@@ -0,0 +1,269 @@
+// Package phpdoc parses PHP doc comments into their summary, description and
+// typed tags.
+package phpdoc
+
+import (
+	"strings"
+)
+
+// DocBlock is a parsed /** */ doc comment.
+type DocBlock struct {
+	Summary     string
+	Description string
+	Tags        []Tag
+}
+
+// Tag is a single @ annotation in a doc comment.
+type Tag interface {
+	TagName() string
+}
+
+// ParamTag documents a function argument: @param type $name description.
+type ParamTag struct {
+	Type        *Type
+	Name        string // the argument name without $
+	ByRef       bool
+	Variadic    bool
+	Description string
+}
+
+// ReturnTag documents a return value: @return type description.
+type ReturnTag struct {
+	Type        *Type
+	Description string
+}
+
+// ThrowsTag documents an exception: @throws type description.
+type ThrowsTag struct {
+	Type        *Type
+	Description string
+}
+
+// VarTag documents a property or variable: @var type [$name] description.
+type VarTag struct {
+	Type        *Type
+	Name        string
+	Description string
+}
+
+// DeprecatedTag marks an element as deprecated: @deprecated [description].
+type DeprecatedTag struct {
+	Description string
+}
+
+// TemplateTag declares a generic type parameter: @template T [of Bound].
+type TemplateTag struct {
+	Name        string
+	Bound       *Type
+	Description string
+}
+
+// GenericTag is any tag without a more specific representation.
+type GenericTag struct {
+	Name  string
+	Value string
+}
+
+func (t *ParamTag) TagName() string      { return "param" }
+func (t *ReturnTag) TagName() string     { return "return" }
+func (t *ThrowsTag) TagName() string     { return "throws" }
+func (t *VarTag) TagName() string        { return "var" }
+func (t *DeprecatedTag) TagName() string { return "deprecated" }
+func (t *TemplateTag) TagName() string   { return "template" }
+func (t *GenericTag) TagName() string    { return t.Name }
+
+// Parse parses a doc comment, with or without its /** */ delimiters. Parsing
+// is lenient: tags that cannot be understood are kept as GenericTags.
+func Parse(comment string) *DocBlock {
+	doc := &DocBlock{}
+	var text []string
+	var tags []string
+	for _, line := range commentLines(comment) {
+		switch {
+		case strings.HasPrefix(line, "@"):
+			tags = append(tags, line)
+		case len(tags) > 0:
+			// continuation of the previous tag's description
+			if line != "" {
+				tags[len(tags)-1] += "\n" + line
+			}
+		default:
+			text = append(text, line)
+		}
+	}
+
+	paragraphs := strings.SplitN(strings.TrimSpace(strings.Join(text, "\n")), "\n\n", 2)
+	doc.Summary = paragraphs[0]
+	if len(paragraphs) > 1 {
+		doc.Description = strings.TrimSpace(paragraphs[1])
+	}
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, parseTag(tag))
+	}
+	return doc
+}
+
+// commentLines strips the comment delimiters and leading asterisks from each
+// line of a doc comment.
+func commentLines(comment string) []string {
+	comment = strings.TrimSpace(comment)
+	comment = strings.TrimPrefix(comment, "/**")
+	comment = strings.TrimSuffix(comment, "*/")
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "*") {
+			line = strings.TrimSpace(line[1:])
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+func parseTag(tag string) Tag {
+	name, body := splitWord(tag[1:])
+	switch name {
+	case "param":
+		t := &ParamTag{}
+		if !isVariableWord(body) {
+			t.Type, body = parseTypeWord(body)
+		}
+		if word, rest := splitWord(body); isVariableWord(word) {
+			t.ByRef = strings.HasPrefix(word, "&")
+			word = strings.TrimPrefix(word, "&")
+			t.Variadic = strings.HasPrefix(word, "...")
+			t.Name = strings.TrimPrefix(strings.TrimPrefix(word, "..."), "$")
+			body = rest
+		}
+		t.Description = body
+		return t
+	case "return", "returns":
+		t := &ReturnTag{}
+		t.Type, t.Description = parseTypeWord(body)
+		return t
+	case "throws", "throw":
+		t := &ThrowsTag{}
+		t.Type, t.Description = parseTypeWord(body)
+		return t
+	case "var":
+		t := &VarTag{}
+		t.Type, body = parseTypeWord(body)
+		if word, rest := splitWord(body); strings.HasPrefix(word, "$") {
+			t.Name = word[1:]
+			body = rest
+		}
+		t.Description = body
+		return t
+	case "deprecated":
+		return &DeprecatedTag{Description: body}
+	case "template", "template-covariant", "template-contravariant":
+		t := &TemplateTag{}
+		t.Name, body = splitWord(body)
+		if word, rest := splitWord(body); word == "of" || word == "as" {
+			t.Bound, body = parseTypeWord(rest)
+		}
+		t.Description = body
+		return t
+	}
+	return &GenericTag{Name: name, Value: body}
+}
+
+// splitWord splits s at its first run of whitespace.
+func splitWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t\n"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// isVariableWord reports whether s starts with a variable name, possibly
+// variadic or by reference.
+func isVariableWord(s string) bool {
+	s = strings.TrimPrefix(s, "&")
+	s = strings.TrimPrefix(s, "...")
+	return strings.HasPrefix(s, "$")
+}
+
+// parseTypeWord parses the type expression at the start of s and returns it
+// along with the text that follows. Whitespace inside brackets, as in
+// array<int, Foo>, and a callable signature, as in callable(int): void,
+// belong to the type.
+func parseTypeWord(s string) (*Type, string) {
+	t, rest := parseTypePrefix(strings.TrimSpace(s))
+	return t, strings.TrimSpace(rest)
+}
+
+// Params returns all @param tags.
+func (d *DocBlock) Params() []*ParamTag {
+	var params []*ParamTag
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*ParamTag); ok {
+			params = append(params, t)
+		}
+	}
+	return params
+}
+
+// Param returns the @param tag for the named argument, given without $.
+func (d *DocBlock) Param(name string) *ParamTag {
+	for _, t := range d.Params() {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Return returns the @return tag.
+func (d *DocBlock) Return() *ReturnTag {
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*ReturnTag); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Throws returns all @throws tags.
+func (d *DocBlock) Throws() []*ThrowsTag {
+	var throws []*ThrowsTag
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*ThrowsTag); ok {
+			throws = append(throws, t)
+		}
+	}
+	return throws
+}
+
+// Var returns the first @var tag.
+func (d *DocBlock) Var() *VarTag {
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*VarTag); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Deprecated returns the @deprecated tag, or nil if the element is not
+// deprecated.
+func (d *DocBlock) Deprecated() *DeprecatedTag {
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*DeprecatedTag); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Templates returns all @template tags.
+func (d *DocBlock) Templates() []*TemplateTag {
+	var templates []*TemplateTag
+	for _, tag := range d.Tags {
+		if t, ok := tag.(*TemplateTag); ok {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
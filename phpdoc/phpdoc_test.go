@@ -0,0 +1,190 @@
+package phpdoc
+
+import (
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/parser"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"int", "int"},
+		{"?Foo", "?Foo"},
+		{"int|string|null", "int|string|null"},
+		{"Foo&Bar", "Foo&Bar"},
+		{"int[]", "array<int>"},
+		{"array<int, Foo>", "array<int, Foo>"},
+		{"array< string ,  list<Foo> >", "array<string, list<Foo>>"},
+		{"(Foo&Bar)|null", "Foo&Bar|null"},
+		{"\\Foo\\Bar", "\\Foo\\Bar"},
+		{"callable", "callable"},
+		{"callable(int, string): bool", "callable(int, string): bool"},
+		{"callable(int $a, string ...$rest): void", "callable(int $a, string ...$rest): void"},
+		{"callable(array &$list, int $n=): void", "callable(array &$list, int $n=): void"},
+		{"Closure(Foo&Bar): ?int", "Closure(Foo&Bar): ?int"},
+		{"callable(callable(int): int): int", "callable(callable(int): int): int"},
+		{"callable(): void|null", "callable(): void|null"},
+	}
+	for _, test := range tests {
+		if got := ParseType(test.src).String(); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.src, got, test.want)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	typ := ParseType("callable(int $a, Foo &...$rest=): void")
+	sig := typ.Signature
+	if typ.Name != "callable" || sig == nil {
+		t.Fatalf("got %#v, want a callable with a signature", typ)
+	}
+	if len(sig.Params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(sig.Params))
+	}
+	if p := sig.Params[0]; p.Type.String() != "int" || p.Name != "a" || p.ByRef || p.Variadic || p.Optional {
+		t.Errorf("first parameter is %#v", p)
+	}
+	if p := sig.Params[1]; p.Type.String() != "Foo" || p.Name != "rest" || !p.ByRef || !p.Variadic || !p.Optional {
+		t.Errorf("second parameter is %#v", p)
+	}
+	if sig.Return == nil || sig.Return.String() != "void" {
+		t.Errorf("return type is %v, want void", sig.Return)
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc := Parse(`/**
+	 * Sums numbers.
+	 *
+	 * Numbers that are not integers
+	 * are rounded first.
+	 *
+	 * @param int[] $numbers the numbers
+	 *   to sum
+	 * @param callable(int): int $round
+	 * @param bool &$ok
+	 * @param mixed ...$rest
+	 * @return int the sum
+	 * @throws \InvalidArgumentException
+	 * @deprecated use add()
+	 * @template T of Number
+	 * @see add()
+	 */`)
+	if doc.Summary != "Sums numbers." {
+		t.Errorf("summary is %q", doc.Summary)
+	}
+	if want := "Numbers that are not integers\nare rounded first."; doc.Description != want {
+		t.Errorf("description is %q, want %q", doc.Description, want)
+	}
+	params := []struct {
+		name, typ, description string
+		byRef, variadic        bool
+	}{
+		{"numbers", "array<int>", "the numbers\nto sum", false, false},
+		{"round", "callable(int): int", "", false, false},
+		{"ok", "bool", "", true, false},
+		{"rest", "mixed", "", false, true},
+	}
+	if got := doc.Params(); len(got) != len(params) {
+		t.Fatalf("got %d @param tags, want %d", len(got), len(params))
+	}
+	for i, want := range params {
+		p := doc.Params()[i]
+		if p.Name != want.name || p.Type.String() != want.typ || p.Description != want.description || p.ByRef != want.byRef || p.Variadic != want.variadic {
+			t.Errorf("@param %d is %+v with type %v, want %+v", i, p, p.Type, want)
+		}
+		if doc.Param(want.name) != p {
+			t.Errorf("Param(%q) is not @param %d", want.name, i)
+		}
+	}
+	if r := doc.Return(); r == nil || r.Type.String() != "int" || r.Description != "the sum" {
+		t.Errorf("@return is %+v", r)
+	}
+	if th := doc.Throws(); len(th) != 1 || th[0].Type.String() != "\\InvalidArgumentException" {
+		t.Errorf("@throws is %+v", th)
+	}
+	if d := doc.Deprecated(); d == nil || d.Description != "use add()" {
+		t.Errorf("@deprecated is %+v", d)
+	}
+	if ts := doc.Templates(); len(ts) != 1 || ts[0].Name != "T" || ts[0].Bound.String() != "Number" {
+		t.Errorf("@template is %+v", ts)
+	}
+	last := doc.Tags[len(doc.Tags)-1]
+	if g, ok := last.(*GenericTag); !ok || g.TagName() != "see" || g.Value != "add()" {
+		t.Errorf("last tag is %#v, want @see add()", last)
+	}
+}
+
+func TestVarTag(t *testing.T) {
+	tests := []struct {
+		src, typ, name, description string
+	}{
+		{"/** @var int */", "int", "", ""},
+		{"/** @var ?Foo $foo the foo */", "?Foo", "foo", "the foo"},
+		{"/** @var array<string, int> counts */", "array<string, int>", "", "counts"},
+	}
+	for _, test := range tests {
+		v := Parse(test.src).Var()
+		if v == nil {
+			t.Errorf("%q: no @var tag", test.src)
+			continue
+		}
+		if v.Type.String() != test.typ || v.Name != test.name || v.Description != test.description {
+			t.Errorf("%q: got %+v with type %v", test.src, v, v.Type)
+		}
+	}
+}
+
+func TestASTType(t *testing.T) {
+	tests := []struct {
+		src  string
+		want ast.Type
+	}{
+		{"int", ast.Integer},
+		{"?string", ast.String | ast.Null},
+		{"int|float", ast.Integer | ast.Float},
+		{"Foo", ast.Object},
+		{"Foo&Bar", ast.Object},
+		{"int[]", ast.Array},
+		{"callable(int): void", ast.Function},
+		{"mixed", ast.Unknown},
+	}
+	for _, test := range tests {
+		if got := ParseType(test.src).ASTType(); got != test.want {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+	var undocumented *Type
+	if got := undocumented.ASTType(); got != ast.Unknown {
+		t.Errorf("nil type: got %v, want %v", got, ast.Unknown)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	src := `<?php
+/**
+ * @param int $a
+ * @param callable(string): bool $filter
+ * @return string[]
+ */
+function f($a, $filter, $untyped) {}`
+	nodes, errs := parser.NewParser(src).Parse()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	f := nodes[0].(*ast.FunctionStmt)
+	doc := Function(f)
+	if got := doc.ParamType("filter").String(); got != "callable(string): bool" {
+		t.Errorf("type of $filter is %s", got)
+	}
+	if got := doc.ReturnType().String(); got != "array<string>" {
+		t.Errorf("return type is %s", got)
+	}
+	types := ArgumentTypes(f)
+	if len(types) != 3 || types[0].String() != "int" || types[2] != nil {
+		t.Errorf("argument types are %v", types)
+	}
+}
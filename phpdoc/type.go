@@ -0,0 +1,327 @@
+package phpdoc
+
+import (
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+// Type is a type expression from a doc comment, such as int, ?Foo, A|B,
+// array<int, Foo> or callable(int): void. Exactly one of Name, Union and
+// Intersection is set. The array shorthand Foo[] is represented as
+// array<Foo>.
+type Type struct {
+	Name         string
+	Params       []*Type    // generic arguments of Name
+	Signature    *Signature // signature of a callable or Closure, if given
+	Nullable     bool
+	Union        []*Type
+	Intersection []*Type
+}
+
+// Signature is the parameter list and return type of a callable type, as in
+// callable(int, string ...$rest): bool. Return is nil when no return type is
+// given.
+type Signature struct {
+	Params []*SignatureParam
+	Return *Type
+}
+
+// SignatureParam is a parameter of a callable signature. Name is given
+// without $, and is empty when the parameter is not named. Optional is set
+// for a parameter marked with a trailing =.
+type SignatureParam struct {
+	Type     *Type
+	Name     string
+	ByRef    bool
+	Variadic bool
+	Optional bool
+}
+
+// ParseType parses a doc comment type expression. Malformed input yields as
+// much of the type as could be understood.
+func ParseType(s string) *Type {
+	p := &typeParser{input: s}
+	return p.parseUnion()
+}
+
+// parseTypePrefix parses the type expression at the start of s and returns
+// it along with the text that follows, or nil and s if s does not start with
+// a type.
+func parseTypePrefix(s string) (*Type, string) {
+	p := &typeParser{input: s}
+	t := p.parseUnion()
+	if p.pos == 0 {
+		return nil, s
+	}
+	return t, s[p.pos:]
+}
+
+type typeParser struct {
+	input string
+	pos   int
+}
+
+func (p *typeParser) peek() byte {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos == len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *typeParser) accept(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *typeParser) parseUnion() *Type {
+	t := p.parseIntersection()
+	if p.peek() != '|' {
+		return t
+	}
+	union := &Type{Union: []*Type{t}}
+	for p.accept('|') {
+		union.Union = append(union.Union, p.parseIntersection())
+	}
+	return union
+}
+
+func (p *typeParser) parseIntersection() *Type {
+	t := p.parsePostfix()
+	if !p.intersects() {
+		return t
+	}
+	intersection := &Type{Intersection: []*Type{t}}
+	for p.intersects() {
+		p.pos++
+		intersection.Intersection = append(intersection.Intersection, p.parsePostfix())
+	}
+	return intersection
+}
+
+// intersects reports whether the next character is an & joining the types
+// of an intersection, rather than one marking a parameter passed by
+// reference, as in Foo &$bar.
+func (p *typeParser) intersects() bool {
+	if p.peek() != '&' {
+		return false
+	}
+	rest := strings.TrimLeft(p.input[p.pos+1:], " \t\n")
+	return rest != "" && rest[0] != '$' && !strings.HasPrefix(rest, "...")
+}
+
+func (p *typeParser) parsePostfix() *Type {
+	t := p.parseAtom()
+	for p.peek() == '[' && strings.HasPrefix(p.input[p.pos:], "[]") {
+		p.pos += 2
+		t = &Type{Name: "array", Params: []*Type{t}}
+	}
+	return t
+}
+
+func (p *typeParser) parseAtom() *Type {
+	if p.accept('?') {
+		t := p.parseAtom()
+		t.Nullable = true
+		return t
+	}
+	if p.accept('(') {
+		t := p.parseUnion()
+		p.accept(')')
+		return t
+	}
+	t := &Type{Name: p.parseName()}
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		// a signature follows the name directly, as in callable(int): void
+		p.pos++
+		t.Signature = p.parseSignature()
+		return t
+	}
+	if p.accept('<') {
+		for {
+			t.Params = append(t.Params, p.parseUnion())
+			if !p.accept(',') {
+				break
+			}
+		}
+		p.accept('>')
+	}
+	if p.peek() == '{' {
+		// array shapes are kept verbatim as part of the name
+		start, depth := p.pos, 0
+		for ; p.pos < len(p.input); p.pos++ {
+			if p.input[p.pos] == '{' {
+				depth++
+			} else if p.input[p.pos] == '}' {
+				depth--
+				if depth == 0 {
+					p.pos++
+					break
+				}
+			}
+		}
+		t.Name += p.input[start:p.pos]
+	}
+	return t
+}
+
+// parseSignature parses the parameters of a callable signature, following
+// its opening parenthesis, and its return type. The return type binds
+// tighter than a union, so callable(): int|null is a nullable callable.
+func (p *typeParser) parseSignature() *Signature {
+	sig := &Signature{}
+	for p.peek() != ')' && p.peek() != 0 {
+		param := &SignatureParam{Type: p.parseUnion()}
+		param.ByRef = p.accept('&')
+		if p.peek() == '.' && strings.HasPrefix(p.input[p.pos:], "...") {
+			p.pos += len("...")
+			param.Variadic = true
+		}
+		if p.accept('$') {
+			param.Name = p.parseName()
+		}
+		param.Optional = p.accept('=')
+		sig.Params = append(sig.Params, param)
+		if !p.accept(',') {
+			break
+		}
+	}
+	p.accept(')')
+	if p.accept(':') {
+		sig.Return = p.parsePostfix()
+	}
+	return sig
+}
+
+func (p *typeParser) parseName() string {
+	p.peek()
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte(" \t\n|&<>,()[]?{}=", p.input[p.pos]) < 0 {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// String renders the type in its canonical form.
+func (t *Type) String() string {
+	var s string
+	switch {
+	case t.Union != nil:
+		s = joinTypes(t.Union, "|")
+	case t.Intersection != nil:
+		s = joinTypes(t.Intersection, "&")
+	case t.Params != nil:
+		s = t.Name + "<" + joinTypes(t.Params, ", ") + ">"
+	case t.Signature != nil:
+		s = t.Name + t.Signature.String()
+	default:
+		s = t.Name
+	}
+	if t.Nullable {
+		return "?" + s
+	}
+	return s
+}
+
+// String renders the signature in its canonical form, as it follows the name
+// of a callable type.
+func (sig *Signature) String() string {
+	list := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		s := param.Type.String()
+		if param.ByRef || param.Variadic || param.Name != "" {
+			s += " "
+		}
+		if param.ByRef {
+			s += "&"
+		}
+		if param.Variadic {
+			s += "..."
+		}
+		if param.Name != "" {
+			s += "$" + param.Name
+		}
+		if param.Optional {
+			s += "="
+		}
+		list[i] = s
+	}
+	s := "(" + strings.Join(list, ", ") + ")"
+	if sig.Return != nil {
+		s += ": " + sig.Return.String()
+	}
+	return s
+}
+
+func joinTypes(types []*Type, sep string) string {
+	list := make([]string, len(types))
+	for i, t := range types {
+		list[i] = t.String()
+	}
+	return strings.Join(list, sep)
+}
+
+var astTypes = map[string]ast.Type{
+	"int":              ast.Integer,
+	"integer":          ast.Integer,
+	"positive-int":     ast.Integer,
+	"negative-int":     ast.Integer,
+	"float":            ast.Float,
+	"double":           ast.Float,
+	"numeric":          ast.Numeric,
+	"string":           ast.String,
+	"class-string":     ast.String,
+	"non-empty-string": ast.String,
+	"bool":             ast.Boolean,
+	"boolean":          ast.Boolean,
+	"true":             ast.Boolean,
+	"false":            ast.Boolean,
+	"scalar":           ast.String | ast.Numeric | ast.Boolean,
+	"null":             ast.Null,
+	"void":             ast.Null,
+	"never":            0,
+	"resource":         ast.Resource,
+	"array":            ast.Array,
+	"list":             ast.Array,
+	"non-empty-array":  ast.Array,
+	"non-empty-list":   ast.Array,
+	"iterable":         ast.Array | ast.Object,
+	"callable":         ast.Function,
+	"mixed":            ast.Unknown,
+}
+
+// ASTType returns the bitmask of values the type admits. Class names map to
+// ast.Object, and a nil type, being undocumented, admits anything.
+func (t *Type) ASTType() ast.Type {
+	if t == nil {
+		return ast.Unknown
+	}
+	var typ ast.Type
+	switch {
+	case t.Union != nil:
+		for _, u := range t.Union {
+			typ |= u.ASTType()
+		}
+	case t.Intersection != nil:
+		typ = ast.Object
+	default:
+		name := strings.ToLower(t.Name)
+		if i := strings.IndexByte(name, '{'); i >= 0 {
+			name = name[:i]
+		}
+		var ok bool
+		if typ, ok = astTypes[name]; !ok {
+			typ = ast.Object
+		}
+	}
+	if t.Nullable {
+		typ |= ast.Null
+	}
+	return typ
+}
@@ -0,0 +1,221 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/lexer"
+	"github.com/jxwr/php-parser/token"
+)
+
+// Format writes f, as returned by Parser.ParseFile, to w formatted as Fprint
+// would format its nodes, but keeping the comments of the source that sit
+// above or beside statements and class members, or at the end of a block or
+// the file. A comment anywhere else, such as within an expression, would be
+// lost, so Format returns a *CommentError and writes nothing if the source
+// has one.
+func Format(w io.Writer, f *ast.File) error {
+	p := &printer{file: f}
+	p.fileNodes()
+	if err := lostComment(f.Tokens, p.buf.String()); err != nil {
+		return err
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// A CommentError reports a comment of the source that Format has no place
+// for.
+type CommentError struct {
+	Pos     token.Position
+	Comment string
+}
+
+func (e *CommentError) Error() string {
+	return fmt.Sprintf("%s: cannot keep comment %s when formatting", e.Pos, e.Comment)
+}
+
+// lostComment returns a *CommentError for the first comment among tokens
+// that is missing from out, the formatted source, or nil if there is none.
+func lostComment(tokens []token.Item, out string) error {
+	var printed []string
+	l := lexer.NewLexer(out)
+	for t := l.Next(); t.Typ != token.EOF && t.Typ != token.Error; t = l.Next() {
+		if t.Typ == token.Comment {
+			printed = append(printed, commentText(t.Val))
+		}
+	}
+	i := 0
+	for _, t := range tokens {
+		if t.Typ != token.Comment {
+			continue
+		}
+		// the output can have more comments than the source, as a doc
+		// comment is repeated for each property of a single declaration
+		for text := commentText(t.Val); i < len(printed) && printed[i] != text; i++ {
+		}
+		if i == len(printed) {
+			comment := strings.TrimSpace(t.Val)
+			if j := strings.IndexByte(comment, '\n'); j >= 0 {
+				comment = comment[:j] + "..."
+			}
+			return &CommentError{Pos: t.Begin, Comment: comment}
+		}
+		i++
+	}
+	return nil
+}
+
+// commentText returns the text of a comment without the indentation of its
+// lines, which the printer may change.
+func commentText(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// comments writes the comments above n in the source on lines of their own.
+// They would otherwise be lost when the node holding n is formatted rather
+// than copied. A comment matching doc is left for n to write as its doc
+// comment.
+func (p *printer) comments(n spanned, doc string) {
+	if p.file == nil || n.Pos().Line == 0 {
+		return
+	}
+	for _, c := range p.commentsBefore(n.Pos(), doc) {
+		p.print(c)
+		p.newline()
+	}
+}
+
+// closingComments writes the comments ending the block or class body whose
+// closing token ends at end, each on a line of its own. A position of -1
+// stands for the end of the file.
+func (p *printer) closingComments(end token.Position) {
+	if p.file == nil || end.Line == 0 && end.Position >= 0 {
+		return
+	}
+	closing := end
+	if end.Position >= 0 {
+		tokens := p.file.Tokens
+		i := sort.Search(len(tokens), func(i int) bool {
+			return tokens[i].End.Position >= end.Position
+		})
+		if i == len(tokens) {
+			return
+		}
+		closing = tokens[i].Begin
+	}
+	for _, c := range p.commentsBefore(closing, "") {
+		p.newline()
+		p.print(c)
+	}
+}
+
+// commentsBefore returns the comments in the run of whitespace and comments
+// preceding the token at pos, or at the end of the file if pos is -1. A
+// comment ending the line of an earlier token belongs to that token instead.
+func (p *printer) commentsBefore(pos token.Position, doc string) []string {
+	tokens := p.file.Tokens
+	i := len(tokens)
+	if pos.Position >= 0 {
+		i = sort.Search(len(tokens), func(i int) bool {
+			return tokens[i].Begin.Position >= pos.Position
+		})
+	}
+	j := i
+	for j > 0 && (isTrivia(tokens[j-1]) || tokens[j-1].Typ == token.EOF) {
+		j--
+	}
+	var comments []string
+	for ; j < i; j++ {
+		t := tokens[j]
+		if t.Typ != token.Comment || t.Val == doc || endsLine(tokens, j) || p.written[t.Begin.Position] {
+			continue
+		}
+		comments = append(comments, strings.TrimRight(t.Val, " \t"))
+		p.wrote(t)
+	}
+	return comments
+}
+
+// wrote records that the comment t has been written.
+func (p *printer) wrote(t token.Item) {
+	if p.written == nil {
+		p.written = make(map[int]bool)
+	}
+	p.written[t.Begin.Position] = true
+}
+
+// trailingComment writes the comment following n on the same line in the
+// source, if there is one.
+func (p *printer) trailingComment(n spanned) {
+	if p.file == nil || n.End().Line == 0 {
+		return
+	}
+	tokens := p.file.Tokens
+	i := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Begin.Position >= n.End().Position
+	})
+	// the semicolon is outside the span of some members
+	if i < len(tokens) && tokens[i].Typ == token.StatementEnd {
+		i++
+	}
+	for j := i; j < len(tokens) && isTrivia(tokens[j]) && tokens[j].Begin.Line == n.End().Line; j++ {
+		if tokens[j].Typ == token.Comment {
+			if p.written[tokens[j].Begin.Position] {
+				return
+			}
+			// FprintFile keeps the comment as it was
+			if p.copying && j > i {
+				p.tokens(tokens[i:j+1], "")
+				return
+			}
+			p.print(" ", strings.TrimRight(tokens[j].Val, " \t"))
+			p.wrote(tokens[j])
+			return
+		}
+	}
+}
+
+// endsLine reports whether the comment at tokens[i] follows some other
+// token on the same line.
+func endsLine(tokens []token.Item, i int) bool {
+	i--
+	if i >= 0 && tokens[i].Typ == token.Space && !strings.Contains(tokens[i].Val, "\n") {
+		i--
+	}
+	return i >= 0 && !isTrivia(tokens[i])
+}
+
+// docOf returns the doc comment n writes itself when it is formatted rather
+// than copied.
+func (p *printer) docOf(n ast.Node) string {
+	if p.file == nil || p.copying && !p.file.Modified(n) {
+		return ""
+	}
+	switch n := n.(type) {
+	case *ast.FunctionStmt:
+		return n.Doc
+	case *ast.Method:
+		if n.FunctionStmt != nil {
+			return n.Doc
+		}
+	case *ast.Class:
+		return n.Doc
+	case *ast.Interface:
+		return n.Doc
+	case *ast.Trait:
+		return n.Doc
+	}
+	return ""
+}
+
+func isTrivia(t token.Item) bool {
+	return t.Typ == token.Space || t.Typ == token.Comment
+}
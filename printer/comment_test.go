@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jxwr/php-parser/parser"
+)
+
+func format(t *testing.T, src string) (string, error) {
+	f, errs := parser.NewParser(src).ParseFile()
+	if len(errs) > 0 {
+		t.Fatalf("%q: unexpected errors: %v", src, errs)
+	}
+	var buf bytes.Buffer
+	err := Format(&buf, f)
+	return buf.String(), err
+}
+
+var formatTests = []struct {
+	src, want string
+}{
+	{
+		"<?php\n/* block */\nfoo();\n",
+		"<?php\n\n/* block */\nfoo();\n",
+	},
+	{
+		"<?php\nfoo();\n/* block */\nbar();\n",
+		"<?php\n\nfoo();\n/* block */\nbar();\n",
+	},
+	{
+		"<?php\nfoo();\n\n// line\nbar(); // trailing\n",
+		"<?php\n\nfoo();\n\n// line\nbar(); // trailing\n",
+	},
+	{
+		"<?php\nfor ($i = 0; $i < 3; $i++); // empty\n",
+		"<?php\n\nfor ($i = 0; $i < 3; $i++) {\n    ; // empty\n}\n",
+	},
+	{
+		"<?php\nclass A {\n/** doc */\nvar $a, $b;\n}\n",
+		"<?php\n\nclass A\n{\n    /** doc */\n    public $a;\n    /** doc */\n    public $b;\n}\n",
+	},
+	{
+		"<?php\nfunction f() {\n    foo();\n    // done\n}\n",
+		"<?php\n\nfunction f()\n{\n    foo();\n    // done\n}\n",
+	},
+	{
+		"<?php\n/*\n   * License\n   */\nnamespace A;\n",
+		"<?php\n\n/*\n   * License\n   */\nnamespace A;\n",
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range formatTests {
+		got, err := format(t, test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q:\ngot\n%s\nwant\n%s", test.src, got, test.want)
+		}
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, test := range formatTests {
+		once, err := format(t, test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		twice, err := format(t, once)
+		if err != nil {
+			t.Errorf("%q: formatting again: %v", test.src, err)
+			continue
+		}
+		if twice != once {
+			t.Errorf("%q: formatting again gave\n%s\ninstead of\n%s", test.src, twice, once)
+		}
+	}
+}
+
+func TestFormatLostComment(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+	}{
+		{"<?php\nfoo($a, /* arg */ $b);\n", 2},
+		{"<?php\n$x = [\n    1, // one\n    2,\n];\n", 3},
+		{"<?php\n$y = $a + /* inner */ $b;\n", 2},
+		{"<?php\nif ($a) {\n    foo();\n} // if\nelse {\n    bar();\n}\n", 4},
+	}
+	for _, test := range tests {
+		got, err := format(t, test.src)
+		cerr, ok := err.(*CommentError)
+		if !ok {
+			t.Errorf("%q: got error %v, want a *CommentError", test.src, err)
+			continue
+		}
+		if cerr.Pos.Line != test.line {
+			t.Errorf("%q: comment reported on line %d, want %d", test.src, cerr.Pos.Line, test.line)
+		}
+		if got != "" {
+			t.Errorf("%q: wrote %q despite the error", test.src, got)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+// Operator precedences, from loosest to tightest binding, following the
+// PHP documentation.
+const (
+	precLowest = iota
+	precWrittenOr
+	precWrittenXor
+	precWrittenAnd
+	precYield
+	precAssignment
+	precTernary
+	precCoalesce
+	precOr
+	precAnd
+	precBitwiseOr
+	precBitwiseXor
+	precBitwiseAnd
+	precEquality
+	precComparison
+	precConcatenation
+	precShift
+	precAddition
+	precMultiplication
+	precInstanceof
+	precNegation
+	precUnary
+	precNew
+	precPrimary
+)
+
+var binaryPrecedence = map[string]int{
+	"or":         precWrittenOr,
+	"xor":        precWrittenXor,
+	"and":        precWrittenAnd,
+	"??":         precCoalesce,
+	"||":         precOr,
+	"&&":         precAnd,
+	"|":          precBitwiseOr,
+	"^":          precBitwiseXor,
+	"&":          precBitwiseAnd,
+	"==":         precEquality,
+	"!=":         precEquality,
+	"===":        precEquality,
+	"!==":        precEquality,
+	"<>":         precEquality,
+	"<=>":        precEquality,
+	"<":          precComparison,
+	"<=":         precComparison,
+	">":          precComparison,
+	">=":         precComparison,
+	".":          precConcatenation,
+	"<<":         precShift,
+	">>":         precShift,
+	"+":          precAddition,
+	"-":          precAddition,
+	"*":          precMultiplication,
+	"/":          precMultiplication,
+	"%":          precMultiplication,
+	"instanceof": precInstanceof,
+}
+
+// casts maps the long forms of the cast operators to the short ones
+// PSR-12 asks for.
+var casts = map[string]string{
+	"(integer)": "(int)",
+	"(boolean)": "(bool)",
+	"(double)":  "(float)",
+	"(real)":    "(float)",
+}
+
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.BinaryExpression:
+		return binaryPrecedence[strings.ToLower(e.Operator)]
+	case *ast.AssignmentExpression:
+		return precAssignment
+	case *ast.TernaryExpression:
+		return precTernary
+	case *ast.Include:
+		// include takes everything to its right as its operand
+		return precAssignment
+	case *ast.YieldExpression, *ast.YieldFromExpression:
+		return precYield
+	case *ast.UnaryExpression:
+		switch {
+		case e.Preceding:
+			return precPrimary
+		case e.Operator == "!":
+			return precNegation
+		case strings.ToLower(e.Operator) == "clone":
+			return precNew
+		}
+		return precUnary
+	case *ast.NewExpression:
+		return precNew
+	}
+	return precPrimary
+}
+
+// concatenationMixes reports whether a and b are a concatenation and an
+// arithmetic or shift operator, whose relative precedence changed in PHP 8
+// and which are therefore always parenthesized when nested.
+func concatenationMixes(a, b string) bool {
+	arithmetic := func(op string) bool {
+		switch op {
+		case "+", "-", "<<", ">>":
+			return true
+		}
+		return false
+	}
+	return a == "." && arithmetic(b) || b == "." && arithmetic(a)
+}
+
+// operand writes e as an operand of a left associative operator with the
+// given precedence, on its right hand side if right is set.
+func (p *printer) operand(e ast.Expression, prec int, op string, right bool) {
+	c := precedence(e)
+	parens := c < prec || c == prec && right
+	if b, ok := e.(*ast.BinaryExpression); ok && concatenationMixes(op, strings.ToLower(b.Operator)) {
+		parens = true
+	}
+	p.parenthesize(e, parens)
+}
+
+func (p *printer) parenthesize(e ast.Expression, parens bool) {
+	if parens {
+		p.print("(")
+		p.expr(e)
+		p.print(")")
+		return
+	}
+	p.expr(e)
+}
+
+// receiver writes e where it is dereferenced by ->, ::, [] or a call, which
+// only some expressions allow without parentheses.
+func (p *printer) receiver(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Variable, *ast.Identifier, *ast.ConstantExpression,
+		*ast.PropertyExpression, *ast.MethodCallExpression,
+		*ast.FunctionCallExpression, *ast.ClassExpression,
+		*ast.ArrayLookupExpression, *ast.ArrayExpression:
+		p.expr(e)
+	case *ast.Literal:
+		p.parenthesize(e, e.Type != ast.String)
+	default:
+		p.parenthesize(e, true)
+	}
+}
+
+// member writes the name following -> or ::, bracing dynamic names.
+func (p *printer) member(name ast.Expression) {
+	switch name := name.(type) {
+	case *ast.Identifier, *ast.Variable, *ast.ConstantExpression, *ast.FunctionCallExpression:
+		p.expr(name)
+	default:
+		p.print("{")
+		p.expr(name)
+		p.print("}")
+	}
+}
+
+func (p *printer) exprList(list []ast.Expression) {
+	for i, e := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expr(e)
+	}
+}
+
+func (p *printer) arguments(args []ast.Expression) {
+	p.print("(")
+	p.exprList(args)
+	p.print(")")
+}
+
+func (p *printer) expr(e ast.Expression) {
+	if e != nil && p.copied(e) {
+		return
+	}
+	switch e := e.(type) {
+	case nil:
+	case *ast.BadExpr:
+		p.bad(e, "/* bad expression */")
+	case *ast.Identifier:
+		p.print(e.Value)
+	case *ast.Variable:
+		p.print("$")
+		switch name := e.Name.(type) {
+		case *ast.Identifier:
+			p.print(name.Value)
+		case *ast.Variable:
+			p.expr(name)
+		default:
+			p.print("{")
+			p.expr(name)
+			p.print("}")
+		}
+	case *ast.ConstantExpression:
+		p.constantName(e.Variable)
+	case *ast.Literal:
+		switch e.Type {
+		case ast.Boolean, ast.Null:
+			p.print(strings.ToLower(e.Value))
+		default:
+			p.print(e.Value)
+		}
+	case *ast.ShellCommand:
+		p.print(e.Command)
+	case *ast.BinaryExpression:
+		op := strings.ToLower(e.Operator)
+		prec := precedence(e)
+		p.operand(e.Antecedent, prec, op, false)
+		p.print(" ", op, " ")
+		p.operand(e.Subsequent, prec, op, true)
+	case *ast.AssignmentExpression:
+		if v, ok := e.Assignee.(ast.Expression); ok {
+			p.expr(v)
+		} else {
+			p.node(e.Assignee)
+		}
+		p.print(" ", e.Operator, " ")
+		// yield needs no parentheses as the value of an assignment
+		p.parenthesize(e.Value, precedence(e.Value) < precYield)
+	case *ast.TernaryExpression:
+		p.parenthesize(e.Condition, precedence(e.Condition) <= precTernary)
+		if e.True == e.Condition {
+			p.print(" ?: ")
+		} else {
+			p.print(" ? ")
+			p.parenthesize(e.True, precedence(e.True) <= precTernary)
+			p.print(" : ")
+		}
+		p.parenthesize(e.False, precedence(e.False) <= precTernary)
+	case *ast.UnaryExpression:
+		p.unary(e)
+	case *ast.NewExpression:
+		p.print("new ")
+		switch class := e.Class.(type) {
+		case *ast.FunctionCallExpression, *ast.MethodCallExpression:
+			// the parser reads the constructor arguments as a call
+			p.expr(class)
+			if len(e.Arguments) == 0 {
+				return
+			}
+		case *ast.Identifier, *ast.ConstantExpression, *ast.Variable,
+			*ast.PropertyExpression, *ast.ClassExpression, *ast.ArrayLookupExpression:
+			p.expr(class)
+		default:
+			p.parenthesize(class, true)
+		}
+		p.arguments(e.Arguments)
+	case *ast.FunctionCallExpression:
+		p.receiver(e.FunctionName)
+		p.arguments(e.Arguments)
+	case *ast.MethodCallExpression:
+		p.receiver(e.Receiver)
+		p.print("->")
+		if e.FunctionCallExpression != nil {
+			p.member(e.FunctionName)
+			p.arguments(e.Arguments)
+		}
+	case *ast.PropertyExpression:
+		p.receiver(e.Receiver)
+		p.print("->")
+		p.member(e.Name)
+	case *ast.ClassExpression:
+		p.receiver(e.Receiver)
+		p.print("::")
+		p.expr(e.Expression)
+	case *ast.ArrayLookupExpression:
+		p.receiver(e.Array)
+		p.print("[")
+		p.expr(e.Index)
+		p.print("]")
+	case *ast.ArrayAppendExpression:
+		p.receiver(e.Array)
+		p.print("[]")
+	case *ast.ArrayExpression:
+		p.array(e)
+	case *ast.Include:
+		p.print(strings.ToLower(e.Operator), " ")
+		p.exprList(e.Expressions)
+	case *ast.YieldExpression:
+		p.print("yield")
+		if e.Key != nil {
+			p.print(" ")
+			p.parenthesize(e.Key, precedence(e.Key) < precYield)
+			p.print(" =>")
+		}
+		if e.Value != nil {
+			p.print(" ")
+			p.parenthesize(e.Value, precedence(e.Value) < precYield)
+		}
+	case *ast.YieldFromExpression:
+		p.print("yield from ")
+		p.parenthesize(e.Source, precedence(e.Source) < precYield)
+	case *ast.CallArgument:
+		if e.Spread {
+			p.print("...")
+		}
+		if e.Name != "" {
+			p.print(e.Name, ": ")
+		}
+		p.expr(e.Value)
+	case *ast.AnonymousFunction:
+		p.print("function ")
+		if e.ByRef {
+			p.print("&")
+		}
+		p.functionArguments(e.Arguments)
+		if len(e.ClosureVariables) > 0 {
+			p.print(" use ")
+			p.functionArguments(e.ClosureVariables)
+		}
+		p.returnType(e.ReturnType)
+		p.print(" ")
+		p.block(e.Body)
+	}
+}
+
+// constantName writes the name of a constant, which the parser stores as a
+// variable.
+func (p *printer) constantName(v *ast.Variable) {
+	if v == nil {
+		return
+	}
+	if name, ok := v.Name.(*ast.Identifier); ok {
+		p.print(name.Value)
+		return
+	}
+	p.expr(v.Name)
+}
+
+func (p *printer) unary(e *ast.UnaryExpression) {
+	op := strings.ToLower(e.Operator)
+	if e.Preceding {
+		p.receiver(e.Operand)
+		p.print(op)
+		return
+	}
+	switch {
+	case op == "clone":
+		p.print("clone ")
+	case strings.HasPrefix(op, "("):
+		op = strings.Replace(op, " ", "", -1)
+		if short, ok := casts[op]; ok {
+			op = short
+		}
+		p.print(op, " ")
+	default:
+		p.print(op)
+	}
+	parens := precedence(e.Operand) < precedence(e)
+	if u, ok := e.Operand.(*ast.UnaryExpression); ok && !u.Preceding {
+		// keep - -$a from reading as --$a
+		sign := strings.HasPrefix(u.Operator, "-") || strings.HasPrefix(u.Operator, "+")
+		parens = parens || (op == "-" || op == "+") && sign
+	}
+	p.parenthesize(e.Operand, parens)
+}
+
+// array writes an array in the short syntax, one pair to a line if the
+// source spread it over several.
+func (p *printer) array(e *ast.ArrayExpression) {
+	multiline := len(e.Pairs) > 0 && e.Pos().Line > 0 && e.End().Line > e.Pos().Line
+	p.print("[")
+	if multiline {
+		p.indent++
+	}
+	for i, pair := range e.Pairs {
+		if multiline {
+			p.newline()
+		} else if i > 0 {
+			p.print(", ")
+		}
+		if pair.Key != nil {
+			p.expr(pair.Key)
+			p.print(" => ")
+		}
+		p.expr(pair.Value)
+		if multiline {
+			p.print(",")
+		}
+	}
+	if multiline {
+		p.indent--
+		p.newline()
+	}
+	p.print("]")
+}
@@ -0,0 +1,401 @@
+package printer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+func (p *printer) function(f *ast.FunctionStmt) {
+	p.functionDefinition(f.FunctionDefinition)
+	if f.Body == nil {
+		p.print(";")
+		return
+	}
+	p.newline()
+	p.block(f.Body)
+}
+
+func (p *printer) functionDefinition(def *ast.FunctionDefinition) {
+	p.print("function ")
+	if def == nil {
+		p.print("()")
+		return
+	}
+	if def.ByRef {
+		p.print("&")
+	}
+	p.print(def.Name)
+	p.functionArguments(def.Arguments)
+	p.returnType(def.ReturnType)
+}
+
+func (p *printer) returnType(t *ast.TypeExpr) {
+	if t != nil {
+		p.print(": ", t.String())
+	}
+}
+
+func (p *printer) functionArguments(args []*ast.FunctionArgument) {
+	p.print("(")
+	for i, arg := range args {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.part(arg)
+	}
+	p.print(")")
+}
+
+func (p *printer) functionArgument(arg *ast.FunctionArgument) {
+	if arg.TypeHint != nil {
+		p.part(arg.TypeHint)
+		p.print(" ")
+	}
+	if arg.ByRef {
+		p.print("&")
+	}
+	if arg.Variadic {
+		p.print("...")
+	}
+	p.expr(arg.Variable)
+	if arg.Default != nil {
+		p.print(" = ")
+		p.expr(arg.Default)
+	}
+}
+
+// part writes a node that is part of a declaration rather than a statement
+// or an expression.
+func (p *printer) part(n ast.Node) {
+	if p.copied(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.FunctionArgument:
+		p.functionArgument(n)
+	case *ast.TypeExpr:
+		p.print(n.String())
+	case *ast.Constant:
+		p.constant(n)
+	case *ast.Property:
+		p.property(n)
+	}
+}
+
+func (p *printer) class(c *ast.Class) {
+	p.doc(c.Doc)
+	switch {
+	case c.Abstract:
+		p.print("abstract ")
+	case c.Final:
+		p.print("final ")
+	}
+	p.print("class ", c.Name)
+	if c.Extends != "" {
+		p.print(" extends ", c.Extends)
+	}
+	if len(c.Implements) > 0 {
+		p.print(" implements ", strings.Join(c.Implements, ", "))
+	}
+	p.members(c, classMembers(c.TraitUses, c.Constants, c.Properties, c.Methods), false)
+}
+
+func (p *printer) trait(t *ast.Trait) {
+	p.doc(t.Doc)
+	p.print("trait ", t.Name)
+	p.members(t, classMembers(t.TraitUses, t.Constants, t.Properties, t.Methods), false)
+}
+
+// classMembers lists the members of a class or trait body.
+func classMembers(uses []*ast.TraitUse, constants []*ast.Constant, properties []*ast.Property, methods []*ast.Method) []spanned {
+	var members []spanned
+	for _, u := range uses {
+		members = append(members, u)
+	}
+	for _, c := range constants {
+		members = append(members, c)
+	}
+	for _, prop := range properties {
+		members = append(members, prop)
+	}
+	for _, m := range methods {
+		members = append(members, m)
+	}
+	return members
+}
+
+func (p *printer) iface(i *ast.Interface) {
+	p.doc(i.Doc)
+	p.print("interface ", i.Name)
+	if len(i.Inherits) > 0 {
+		p.print(" extends ", strings.Join(i.Inherits, ", "))
+	}
+	var members []spanned
+	for _, c := range i.Constants {
+		members = append(members, c)
+	}
+	for _, m := range i.Methods {
+		members = append(members, m)
+	}
+	p.members(i, members, true)
+}
+
+// members writes the body of owner, a class, interface or trait.
+func (p *printer) members(owner ast.Node, members []spanned, inInterface bool) {
+	sortMembers(members)
+	p.newline()
+	p.print("{")
+	p.indent++
+	open, close, braced := p.braces(owner)
+	margin, indent := p.margin, p.indent
+	for _, m := range members {
+		if braced && p.align(m.Pos()) {
+			break
+		}
+	}
+	for i, m := range members {
+		doc := p.memberDoc(m)
+		switch {
+		case i == 0 && braced && p.gap(open, m.Pos(), doc):
+		case i > 0 && p.gap(p.memberEnd(members[i-1]), m.Pos(), doc):
+		default:
+			if i > 0 {
+				p.trailingComment(members[i-1])
+			}
+			p.newline()
+			if i > 0 && p.memberBlankLine(members[i-1], m) {
+				p.newline()
+			}
+			p.comments(m, doc)
+		}
+		p.classMember(m, inInterface)
+	}
+	closed := false
+	if n := len(members); n > 0 {
+		closed = braced && p.gap(p.memberEnd(members[n-1]), close, "")
+		if !closed {
+			p.trailingComment(members[n-1])
+		}
+	}
+	p.margin, p.indent = margin, indent
+	if closed {
+		p.indent--
+		p.print("}")
+		return
+	}
+	p.closingComments(owner.End())
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// sortMembers puts members back in source order, as the AST keeps
+// constants, properties and methods apart. A member without a position, one
+// added since the source was parsed, stays after the member listed before
+// it.
+func sortMembers(members []spanned) {
+	type keyed struct {
+		key    int
+		member spanned
+	}
+	list := make([]keyed, len(members))
+	key := -1
+	for i, m := range members {
+		if m.Pos().Line != 0 {
+			key = m.Pos().Position
+		}
+		list[i] = keyed{key, m}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].key < list[j].key
+	})
+	for i := range list {
+		members[i] = list[i].member
+	}
+}
+
+func (p *printer) classMember(m spanned, inInterface bool) {
+	switch m := m.(type) {
+	case *ast.TraitUse:
+		if !p.copied(m) {
+			p.traitUse(m)
+		}
+	case *ast.Constant:
+		if !p.copied(m) {
+			p.doc(m.Doc)
+			p.constant(m)
+		}
+	case *ast.Property:
+		if tokens := p.declaration(m); tokens != nil && p.unchanged(m) {
+			p.copyNode(m, tokens)
+			return
+		}
+		p.doc(m.Doc)
+		p.property(m)
+		p.print(";")
+	case *ast.Method:
+		if !p.copied(m) {
+			p.method(m, inInterface)
+		}
+	}
+}
+
+// memberDoc returns the doc comment m writes itself, which is its own unless
+// it is copied from the source.
+func (p *printer) memberDoc(m spanned) string {
+	switch m := m.(type) {
+	case *ast.Constant:
+		if !p.unchanged(m) {
+			return m.Doc
+		}
+	case *ast.Property:
+		if p.declaration(m) == nil || !p.unchanged(m) {
+			return m.Doc
+		}
+	case *ast.Method:
+		return p.docOf(m)
+	}
+	return ""
+}
+
+// memberEnd returns where m ends in the source, which for a property is
+// after the semicolon ending its declaration.
+func (p *printer) memberEnd(m spanned) token.Position {
+	if prop, ok := m.(*ast.Property); ok {
+		if tokens := p.declaration(prop); tokens != nil {
+			return tokens[len(tokens)-1].End
+		}
+	}
+	return m.End()
+}
+
+// declaration returns the tokens of the declaration of prop, which unlike
+// prop itself end with a semicolon, or nil if prop shares its declaration
+// with other properties or has no source.
+func (p *printer) declaration(prop *ast.Property) []token.Item {
+	if p.file == nil || prop.Pos().Line == 0 {
+		return nil
+	}
+	tokens := p.file.Tokens
+	i := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Begin.Position >= prop.Pos().Position
+	})
+	j := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Begin.Position >= prop.End().Position
+	})
+	for j < len(tokens) && isTrivia(tokens[j]) {
+		j++
+	}
+	if j == len(tokens) || tokens[j].Typ != token.StatementEnd {
+		return nil
+	}
+	for k := i - 1; k >= 0; k-- {
+		if tokens[k].Typ == token.Comma {
+			return nil
+		}
+		if !isTrivia(tokens[k]) {
+			break
+		}
+	}
+	return tokens[i : j+1]
+}
+
+// memberBlankLine reports whether two members are set apart by a blank line,
+// which is always the case around methods and between members of different
+// kinds.
+func (p *printer) memberBlankLine(prev, next spanned) bool {
+	_, prevMethod := prev.(*ast.Method)
+	_, nextMethod := next.(*ast.Method)
+	_, prevConst := prev.(*ast.Constant)
+	_, nextConst := next.(*ast.Constant)
+	_, prevUse := prev.(*ast.TraitUse)
+	_, nextUse := next.(*ast.TraitUse)
+	return prevMethod || nextMethod || prevConst != nextConst || prevUse != nextUse || p.hasBlankLine(prev, next)
+}
+
+// traitUse writes the use of traits, followed by its conflict resolution
+// block if it has one.
+func (p *printer) traitUse(u *ast.TraitUse) {
+	p.print("use ", strings.Join(u.Traits, ", "))
+	if len(u.Rules) == 0 {
+		p.print(";")
+		return
+	}
+	p.print(" {")
+	p.indent++
+	for _, r := range u.Rules {
+		p.newline()
+		if r.Trait != "" {
+			p.print(r.Trait, "::")
+		}
+		p.print(r.Method)
+		if len(r.InsteadOf) > 0 {
+			p.print(" insteadof ", strings.Join(r.InsteadOf, ", "), ";")
+			continue
+		}
+		p.print(" as")
+		if r.ChangesVisibility {
+			p.print(" ", r.Visibility.String())
+		}
+		if r.Alias != "" {
+			p.print(" ", r.Alias)
+		}
+		p.print(";")
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// constant writes a class constant without its doc comment, which lies
+// outside of its span.
+func (p *printer) constant(c *ast.Constant) {
+	p.print("const ")
+	p.constantName(c.Variable)
+	if v, ok := c.Value.(ast.Expression); ok {
+		p.print(" = ")
+		p.expr(v)
+	}
+	p.print(";")
+}
+
+// property writes a property without its doc comment or the semicolon
+// following it, which lie outside of its span.
+func (p *printer) property(prop *ast.Property) {
+	p.print(prop.Visibility.String(), " ")
+	if prop.Static {
+		p.print("static ")
+	}
+	if prop.TypeHint != nil {
+		p.print(prop.TypeHint.String(), " ")
+	}
+	p.print(prop.Name)
+	if prop.Initialization != nil {
+		p.print(" = ")
+		p.expr(prop.Initialization)
+	}
+}
+
+// method writes a method with its modifiers in the order PSR-12 gives:
+// abstract or final, then visibility, then static. Interface methods are
+// abstract without saying so.
+func (p *printer) method(m *ast.Method, inInterface bool) {
+	if m.FunctionStmt == nil {
+		return
+	}
+	p.doc(m.Doc)
+	switch {
+	case m.Abstract && !inInterface:
+		p.print("abstract ")
+	case m.Final:
+		p.print("final ")
+	}
+	p.print(m.Visibility.String(), " ")
+	if m.Static {
+		p.print("static ")
+	}
+	p.function(m.FunctionStmt)
+}
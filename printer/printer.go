@@ -0,0 +1,261 @@
+// Package printer renders AST nodes as PHP source code, formatted in the
+// PSR-12 style.
+//
+// An echo of a single unquoted string literal, which is how the parser
+// represents inline HTML, is printed as inline HTML rather than as an echo.
+//
+// FprintFile prints a file from Parser.ParseFile losslessly instead,
+// formatting only the nodes that have changed since it was parsed, and
+// Format formats such a file while keeping its comments.
+package printer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+const indentation = "    "
+
+// Fprint writes the source of a file made up of nodes, such as those
+// returned by Parser.Parse, to w.
+func Fprint(w io.Writer, nodes []ast.Node) error {
+	p := &printer{}
+	p.nodes(nodes)
+	p.newline()
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// Sprint returns the source of a single node, without an opening tag.
+func Sprint(n ast.Node) string {
+	p := &printer{php: true}
+	p.node(n)
+	return p.buf.String()
+}
+
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+
+	// php is set while the output is inside a PHP section rather than
+	// inline HTML.
+	php bool
+
+	// bol is set at the beginning of a line, before its indentation has
+	// been written.
+	bol bool
+
+	// file is the parsed file being printed by FprintFile or Format, whose
+	// comments are kept.
+	file *ast.File
+
+	// copying is set by FprintFile to copy the unchanged nodes of file
+	// from its source.
+	copying bool
+
+	// margin is written at the beginning of each line, before the
+	// indentation, to line changed nodes up with the source copied around
+	// them.
+	margin string
+
+	// written holds the offsets of the comments of file written so far,
+	// since nodes that end together, as a loop and its empty body do,
+	// would each write the comment following them.
+	written map[int]bool
+}
+
+// print writes each string in turn, indenting it if it starts a line and
+// opening a PHP section first if the output is in inline HTML.
+func (p *printer) print(args ...string) {
+	if !p.php {
+		if p.buf.Len() == 0 {
+			p.buf.WriteString("<?php\n\n")
+		} else {
+			p.buf.WriteString("<?php\n")
+		}
+		p.php, p.bol = true, true
+	}
+	for _, s := range args {
+		if s == "" {
+			continue
+		}
+		p.startLine()
+		p.buf.WriteString(s)
+	}
+}
+
+// startLine writes the margin and indentation at the beginning of a line.
+func (p *printer) startLine() {
+	if p.bol {
+		p.buf.WriteString(p.margin)
+		p.buf.WriteString(strings.Repeat(indentation, p.indent))
+		p.bol = false
+	}
+}
+
+// newline ends the current line. It does nothing in inline HTML, where
+// whitespace is significant.
+func (p *printer) newline() {
+	if p.php {
+		p.buf.WriteByte('\n')
+		p.bol = true
+	}
+}
+
+// html writes inline HTML, closing the current PHP section.
+func (p *printer) html(text string) {
+	if p.php {
+		p.print("?>")
+		p.php = false
+	}
+	p.buf.WriteString(text)
+}
+
+// doc writes a doc comment on lines of its own, reindented to the current
+// level.
+func (p *printer) doc(doc string) {
+	if doc == "" {
+		return
+	}
+	for i, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			if strings.HasPrefix(line, "*") {
+				line = " " + line
+			}
+			p.newline()
+		}
+		p.print(line)
+	}
+	p.newline()
+}
+
+func (p *printer) node(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.FunctionArgument, *ast.TypeExpr, *ast.Constant, *ast.Property:
+		p.part(n)
+	case ast.Statement:
+		p.stmt(n)
+	case ast.Expression:
+		p.expr(n)
+	}
+}
+
+// nodes writes a list of nodes. The comments before the first node and
+// after the last are left to the caller.
+func (p *printer) nodes(list []ast.Node) {
+	for i, n := range list {
+		if i > 0 {
+			p.between(list[i-1], n)
+		}
+		p.node(n)
+	}
+}
+
+// statements writes a list of statements like nodes.
+func (p *printer) statements(list []ast.Statement) {
+	for i, s := range list {
+		if i > 0 {
+			p.between(list[i-1], s)
+		}
+		p.stmt(s)
+	}
+}
+
+// between writes what comes between two nodes of a list: the source between
+// them if it can be copied, and otherwise the comments around them and a
+// line break.
+func (p *printer) between(prev, next ast.Node) {
+	doc := p.docOf(next)
+	if p.gap(prev.End(), next.Pos(), doc) {
+		return
+	}
+	p.trailingComment(prev)
+	p.separate(prev, next)
+	p.comments(next, doc)
+}
+
+// separate ends the line of prev, leaving a blank line before next where the
+// source had one or where PSR-12 asks for one.
+func (p *printer) separate(prev, next ast.Node) {
+	p.newline()
+	if p.blankLineBetween(prev, next) {
+		p.newline()
+	}
+}
+
+func (p *printer) blankLineBetween(prev, next ast.Node) bool {
+	if isDeclaration(prev) || isDeclaration(next) {
+		return true
+	}
+	if ns, ok := prev.(*ast.NamespaceStmt); ok && ns.Block == nil {
+		return true
+	}
+	if _, ok := prev.(*ast.UseStmt); ok {
+		if _, ok := next.(*ast.UseStmt); !ok {
+			return true
+		}
+	}
+	return p.hasBlankLine(prev, next)
+}
+
+// spanned is anything with a position in the source, including the class
+// members that are not nodes themselves.
+type spanned interface {
+	Pos() token.Position
+	End() token.Position
+}
+
+// hasBlankLine reports whether the source had an empty line between two
+// nodes. The lines of comments between them do not count, so that a comment
+// written on a line of its own does not gain a blank line each time the
+// source is formatted. Nodes built by hand have no positions, and never do.
+func (p *printer) hasBlankLine(prev, next spanned) bool {
+	end, begin := prev.End(), next.Pos()
+	if end.Line == 0 || begin.Line <= end.Line+1 {
+		return false
+	}
+	if p.file == nil {
+		return true
+	}
+	for _, t := range p.file.TokensBetween(end, begin) {
+		if t.Typ == token.Space && strings.Count(t.Val, "\n") > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeclaration(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.FunctionStmt, *ast.Class, *ast.Interface, *ast.Trait:
+		return true
+	case *ast.NamespaceStmt:
+		return n.Block != nil
+	}
+	return false
+}
+
+// inlineHTML returns the text of an echo statement standing for inline
+// HTML.
+func inlineHTML(n ast.Node) (string, bool) {
+	echo, ok := n.(*ast.EchoStmt)
+	if !ok || len(echo.Expressions) != 1 {
+		return "", false
+	}
+	lit, ok := echo.Expressions[0].(*ast.Literal)
+	if !ok || lit.Type != ast.String || isQuoted(lit.Value) {
+		return "", false
+	}
+	return lit.Value, true
+}
+
+// isQuoted reports whether a string literal's value carries its quotes, as
+// every string written in PHP code does.
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, "'") || strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "<<<")
+}
@@ -0,0 +1,102 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/parser"
+)
+
+func fprint(t *testing.T, src string) string {
+	nodes, errs := parser.NewParser(src).Parse()
+	if len(errs) > 0 {
+		t.Fatalf("%q: unexpected errors: %v", src, errs)
+	}
+	var buf bytes.Buffer
+	if err := Fprint(&buf, nodes); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+var printTests = []struct {
+	src, want string
+}{
+	{"<?php $a=1+2*3;", "$a = 1 + 2 * 3;\n"},
+	{"<?php $a=(1+2)*3;", "$a = (1 + 2) * 3;\n"},
+	{"<?php $a=$b-($c-$d);", "$a = $b - ($c - $d);\n"},
+	{"<?php $a=!($b&&$c);", "$a = !($b && $c);\n"},
+	{"<?php $a=-($b+1);", "$a = -($b + 1);\n"},
+	{"<?php $a = $b ? : $c;", "$a = $b ?: $c;\n"},
+	{"<?php echo 'a','b';", "echo 'a', 'b';\n"},
+	{"<?php if($a){b();}elseif($c){d();}else{e();}", "if ($a) {\n    b();\n} elseif ($c) {\n    d();\n} else {\n    e();\n}\n"},
+	{"<?php if($a) b();", "if ($a) {\n    b();\n}\n"},
+	{"<?php while($a){}", "while ($a) {\n}\n"},
+	{"<?php do{$a++;}while($a<3);", "do {\n    $a++;\n} while ($a < 3);\n"},
+	{"<?php for($i=0;$i<3;$i++){}", "for ($i = 0; $i < 3; $i++) {\n}\n"},
+	{"<?php foreach($a as $k=>$v){}", "foreach ($a as $k => $v) {\n}\n"},
+	{"<?php foreach($a as &$v){}", "foreach ($a as &$v) {\n}\n"},
+	{"<?php foreach($a as $k=>&$v){}", "foreach ($a as $k => &$v) {\n}\n"},
+	{"<?php switch($a){case 1:b();break;default:c();}", "switch ($a) {\n    case 1:\n        b();\n        break;\n    default:\n        c();\n}\n"},
+	{"<?php try{a();}catch(A|B $e){}finally{c();}", "try {\n    a();\n} catch (A | B $e) {\n} finally {\n    c();\n}\n"},
+	{"<?php function &f(int $a=1,...$b):?string{return $a;}", "function &f(int $a = 1, ...$b): ?string\n{\n    return $a;\n}\n"},
+	{"<?php $f=function($a)use(&$b){return $a;};", "$f = function ($a) use (&$b) {\n    return $a;\n};\n"},
+	{"<?php f(...$a, b: 1);", "f(...$a, b: 1);\n"},
+	{"<?php $a=[1,'k'=>2];", "$a = [1, 'k' => 2];\n"},
+	{"<?php $a->b()->c[0]=A::$d;", "$a->b()->c[0] = A::$d;\n"},
+	{"<?php function g(){yield 1;yield $k=>$v;yield from h();}", "function g()\n{\n    yield 1;\n    yield $k => $v;\n    yield from h();\n}\n"},
+	{"<?php namespace A\\B;use C\\D as E;", "namespace A\\B;\n\nuse C\\D as E;\n"},
+	{
+		"<?php abstract class A extends B implements C,D{use T;const X=1;public static $p;abstract protected function f();final public function g(){}}",
+		"abstract class A extends B implements C, D\n{\n    use T;\n\n    const X = 1;\n\n    public static $p;\n\n    abstract protected function f();\n\n    final public function g()\n    {\n    }\n}\n",
+	},
+	{"<?php interface I extends J{const X=1;function f();}", "interface I extends J\n{\n    const X = 1;\n\n    public function f();\n}\n"},
+	{"<?php trait T{use U{U::f insteadof V;g as protected h;}}", "trait T\n{\n    use U {\n        U::f insteadof V;\n        g as protected h;\n    }\n}\n"},
+	{"<p><?php echo $a; ?></p>", "<p><?php\necho $a;\n?></p>"},
+}
+
+func TestFprint(t *testing.T) {
+	for _, test := range printTests {
+		want := test.want
+		if strings.HasPrefix(test.src, "<?php") {
+			want = "<?php\n\n" + want
+		}
+		if got := fprint(t, test.src); got != want {
+			t.Errorf("%q:\ngot\n%s\nwant\n%s", test.src, got, want)
+		}
+	}
+}
+
+func TestFprintIdempotent(t *testing.T) {
+	for _, test := range printTests {
+		once := fprint(t, test.src)
+		if twice := fprint(t, once); twice != once {
+			t.Errorf("%q: printing again gave\n%s\ninstead of\n%s", test.src, twice, once)
+		}
+	}
+}
+
+func TestSprint(t *testing.T) {
+	tests := []struct {
+		n    ast.Node
+		want string
+	}{
+		{&ast.Variable{Name: &ast.Identifier{Value: "a"}}, "$a"},
+		{
+			&ast.BinaryExpression{
+				Antecedent: &ast.BinaryExpression{Antecedent: &ast.Literal{Type: ast.Float, Value: "1"}, Subsequent: &ast.Literal{Type: ast.Float, Value: "2"}, Operator: "+"},
+				Subsequent: &ast.Literal{Type: ast.Float, Value: "3"},
+				Operator:   "*",
+			},
+			"(1 + 2) * 3",
+		},
+		{&ast.ReturnStmt{}, "return;"},
+	}
+	for _, test := range tests {
+		if got := Sprint(test.n); got != test.want {
+			t.Errorf("%#v: got %q, want %q", test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package printer
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/token"
+)
+
+// FprintFile writes the source of f, as returned by Parser.ParseFile, to w.
+// Nodes that are unchanged since f was parsed are written exactly as they
+// appear in the source, whitespace and comments included, so that an
+// unmodified file is reproduced byte for byte. Changed nodes are formatted
+// as Fprint would format them, although unchanged nodes below them are
+// still copied from the source, and so is the source between statements or
+// class members that were next to each other when f was parsed. Other
+// comments between the children of a changed node are lost.
+func FprintFile(w io.Writer, f *ast.File) error {
+	p := &printer{file: f, copying: true}
+	if f.NodesModified() {
+		p.fileNodes()
+	} else {
+		p.copy(f.Tokens, f.Nodes)
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// fileNodes writes the nodes of the file being printed, along with the
+// comments before the first and after the last of them.
+func (p *printer) fileNodes() {
+	nodes := p.file.Nodes
+	if len(nodes) > 0 {
+		first, last := nodes[0], nodes[len(nodes)-1]
+		if doc := p.docOf(first); !p.gap(token.Position{Line: 1}, first.Pos(), doc) {
+			p.comments(first, doc)
+		}
+		p.nodes(nodes)
+		if tokens := p.file.Tokens; p.gap(last.End(), tokens[len(tokens)-1].End, "") {
+			return
+		}
+		p.trailingComment(last)
+	}
+	p.closingComments(token.Position{Position: -1})
+	p.newline()
+}
+
+// unchanged reports whether n can be copied from the source, which it can
+// if it is unchanged since the file being printed was parsed.
+func (p *printer) unchanged(n ast.Node) bool {
+	return p.copying && n.Pos().Line != 0 && !p.file.Modified(n)
+}
+
+// copied writes n as it appears in the source if it is unchanged, reporting
+// whether it did.
+func (p *printer) copied(n ast.Node) bool {
+	if !p.unchanged(n) {
+		return false
+	}
+	p.copyNode(n, p.file.TokensOf(n))
+	return true
+}
+
+// copyNode writes tokens, the source of n, printing the children of n in
+// place of their tokens.
+func (p *printer) copyNode(n ast.Node, tokens []token.Item) {
+	// the children are printed with a margin of their own, so the line n
+	// starts on is indented first
+	if p.php {
+		p.startLine()
+	}
+	p.copy(tokens, children(n))
+}
+
+// gap writes the source between end, where one node ends, and begin, where
+// the node following it begins, if it is made up of nothing but whitespace,
+// comments, PHP tags and source that could not be lexed, reporting whether
+// it did. It does not when either
+// node is new, or when a node that was between them has been removed. A doc
+// comment matching doc is left for the following node to write itself.
+func (p *printer) gap(end, begin token.Position, doc string) bool {
+	if !p.copying || end.Line == 0 || begin.Line == 0 || end.Position > begin.Position {
+		return false
+	}
+	tokens := p.file.TokensBetween(end, begin)
+	for _, t := range tokens {
+		if !isTrivia(t) && t.Typ != token.PHPBegin && t.Typ != token.PHPEnd && t.Typ != token.Error {
+			return false
+		}
+	}
+	p.tokens(tokens, doc)
+	return true
+}
+
+// tokens writes tokens copied from the source that come before a node, but
+// for the doc comment matching doc, which the node writes itself, and the
+// comments that have been written already.
+func (p *printer) tokens(tokens []token.Item, doc string) {
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Typ == token.Comment && doc != "" && t.Val == doc:
+			// the whitespace after the doc comment goes with it
+			if i+1 < len(tokens) && tokens[i+1].Typ == token.Space {
+				i++
+			}
+			continue
+		case t.Typ == token.Space && i+1 < len(tokens) && p.written[tokens[i+1].Begin.Position]:
+			// a comment written by a node that ended with the one before
+			// it goes along with the space before it
+			continue
+		case t.Typ == token.Comment:
+			if p.written[t.Begin.Position] {
+				continue
+			}
+			p.wrote(t)
+		}
+		p.token(t)
+	}
+}
+
+// align lines the output up with pos, the position of a node copied from
+// the source, if the node starts its line, so that the nodes formatted
+// around it are indented like it. It reports whether it did.
+func (p *printer) align(pos token.Position) bool {
+	if !p.copying || pos.Line == 0 {
+		return false
+	}
+	prefix := p.linePrefix(pos)
+	if strings.TrimLeft(prefix, " \t") != "" {
+		return false
+	}
+	p.margin, p.indent = prefix, 0
+	return true
+}
+
+// braces returns the positions just inside the braces of n, a block or the
+// body of a class, interface or trait, when n has its source.
+func (p *printer) braces(n ast.Node) (open, close token.Position, ok bool) {
+	if !p.copying || n == nil || n.Pos().Line == 0 {
+		return open, close, false
+	}
+	tokens := p.file.TokensOf(n)
+	i := 0
+	for i < len(tokens) && tokens[i].Typ != token.BlockBegin {
+		i++
+	}
+	if _, isBlock := n.(*ast.Block); isBlock && i != 0 || i == len(tokens) || tokens[len(tokens)-1].Typ != token.BlockEnd {
+		return open, close, false
+	}
+	return tokens[i].End, tokens[len(tokens)-1].Begin, true
+}
+
+// bad writes a node that could not be parsed as it appears in the source,
+// which is all that is known of it, or writes placeholder instead if the
+// source is not at hand.
+func (p *printer) bad(n ast.Node, placeholder string) {
+	if p.file == nil || n.Pos().Line == 0 {
+		p.print(placeholder)
+		return
+	}
+	p.copy(p.file.TokensOf(n), nil)
+}
+
+// copy writes tokens, printing each of children in place of the tokens it
+// was parsed from.
+func (p *printer) copy(tokens []token.Item, children []ast.Node) {
+	sorted := make([]ast.Node, len(children))
+	copy(sorted, children)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Pos().Position < sorted[j].Pos().Position
+	})
+	i := 0
+	for _, c := range sorted {
+		// children without positions, or sharing the source of another
+		// child, are part of the tokens already
+		if c.Pos().Line == 0 || i < len(tokens) && c.Pos().Position < tokens[i].Begin.Position {
+			continue
+		}
+		before := i
+		for i < len(tokens) && tokens[i].Begin.Position < c.Pos().Position {
+			i++
+		}
+		p.tokens(tokens[before:i], p.docOf(c))
+		margin, indent := p.margin, p.indent
+		p.margin, p.indent = p.lineMargin(c.Pos()), 0
+		p.node(c)
+		p.margin, p.indent = margin, indent
+		for ; i < len(tokens) && tokens[i].Begin.Position < c.End().Position; i++ {
+		}
+	}
+	p.tokens(tokens[i:], "")
+}
+
+// lineMargin returns the whitespace starting the line of the source on
+// which pos lies.
+func (p *printer) lineMargin(pos token.Position) string {
+	line := p.linePrefix(pos)
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// linePrefix returns the source on the line of pos that comes before it.
+func (p *printer) linePrefix(pos token.Position) string {
+	tokens := p.file.Tokens
+	line := ""
+	for i := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Begin.Position >= pos.Position
+	}) - 1; i >= 0; i-- {
+		val := tokens[i].Val
+		if j := strings.LastIndex(val, "\n"); j >= 0 {
+			line = val[j+1:] + line
+			break
+		}
+		line = val + line
+	}
+	return line
+}
+
+// token writes a token copied from the source, keeping track of whether
+// the output is in PHP or inline HTML.
+func (p *printer) token(t token.Item) {
+	switch t.Typ {
+	case token.EOF:
+	case token.Error:
+		// the source the lexer gave up on, as kept by ParseFile
+		p.buf.WriteString(t.Val)
+	case token.HTML:
+		p.html(t.Val)
+	case token.PHPBegin:
+		if !p.php {
+			p.buf.WriteString(t.Val)
+			p.php, p.bol = true, false
+		}
+	case token.PHPEnd:
+		if p.php {
+			p.buf.WriteString(t.Val)
+			p.php = false
+		}
+	default:
+		p.print(t.Val)
+	}
+}
+
+// children returns the direct children of n.
+func children(n ast.Node) []ast.Node {
+	var list []ast.Node
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c == n {
+			return true
+		}
+		if c != nil {
+			list = append(list, c)
+		}
+		return false
+	})
+	return list
+}
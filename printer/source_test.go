@@ -0,0 +1,260 @@
+package printer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jxwr/php-parser/ast"
+	"github.com/jxwr/php-parser/parser"
+)
+
+const editSource = `<?php
+// header
+namespace App;
+
+use Foo\Bar;
+
+/**
+ * Counter counts.
+ */
+class Counter {
+  const START=0 ;
+
+  /** @var int */
+  private $n=self::START;   // current
+
+  public function inc( $by=1 ) {
+    $this->n+=$by; // add
+    return $this ;
+  }
+
+  // reading
+  public function get() { return $this->n; }
+}
+
+function   helper ( $x )
+{
+    $y = $x*2;
+
+    return $y; // done
+}
+?>
+<p>tail</p>
+`
+
+func call(name string) *ast.ExpressionStmt {
+	return &ast.ExpressionStmt{Expression: &ast.FunctionCallExpression{FunctionName: &ast.Identifier{Value: name}}}
+}
+
+func fprintFile(t *testing.T, src string, edit func(f *ast.File)) string {
+	f, errs := parser.NewParser(src).ParseFile()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if edit != nil {
+		edit(f)
+	}
+	var buf bytes.Buffer
+	if err := FprintFile(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// edited returns editSource with each string of pairs at an even index
+// replaced by the one after it.
+func edited(pairs ...string) string {
+	return strings.NewReplacer(pairs...).Replace(editSource)
+}
+
+func class(f *ast.File) *ast.Class {
+	return f.Nodes[2].(*ast.Class)
+}
+
+func helper(f *ast.File) *ast.FunctionStmt {
+	return f.Nodes[3].(*ast.FunctionStmt)
+}
+
+var editTests = []struct {
+	name string
+	edit func(f *ast.File)
+	want string
+}{
+	{"unchanged", nil, editSource},
+	{
+		"append statement",
+		func(f *ast.File) {
+			f.Nodes = append(f.Nodes, call("done"))
+		},
+		editSource + "<?php\ndone();\n",
+	},
+	{
+		"insert statement",
+		func(f *ast.File) {
+			b := helper(f).Body
+			b.Statements = append([]ast.Statement{b.Statements[0], call("check")}, b.Statements[1:]...)
+		},
+		edited("$y = $x*2;\n\n", "$y = $x*2;\n    check();\n"),
+	},
+	{
+		"delete statement",
+		func(f *ast.File) {
+			b := helper(f).Body
+			b.Statements = b.Statements[1:]
+		},
+		edited("    $y = $x*2;\n\n", ""),
+	},
+	{
+		"replace expression",
+		func(f *ast.File) {
+			ast.Rewrite(helper(f).Body, func(c *ast.Cursor) bool {
+				if r, ok := c.Parent().(*ast.ReturnStmt); ok && c.Node() == r.Expression {
+					c.Replace(&ast.Variable{Name: &ast.Identifier{Value: "x"}})
+				}
+				return true
+			})
+		},
+		edited("return $y; // done", "return $x; // done"),
+	},
+	{
+		"append method",
+		func(f *ast.File) {
+			c := class(f)
+			c.Methods = append(c.Methods, &ast.Method{
+				Visibility: ast.Public,
+				FunctionStmt: &ast.FunctionStmt{
+					FunctionDefinition: &ast.FunctionDefinition{Name: "reset"},
+					Body:               &ast.Block{Statements: []ast.Statement{call("clear")}},
+				},
+			})
+		},
+		edited(
+			"class Counter {", "class Counter\n{",
+			"get() { return $this->n; }\n", "get() { return $this->n; }\n\n  public function reset()\n  {\n      clear();\n  }\n",
+		),
+	},
+	{
+		"append property",
+		func(f *ast.File) {
+			c := class(f)
+			c.Properties = append(c.Properties, &ast.Property{Visibility: ast.Private, Name: "$max"})
+		},
+		edited("class Counter {", "class Counter\n{", "// current\n", "// current\n  private $max;\n"),
+	},
+	{
+		"change property",
+		func(f *ast.File) {
+			class(f).Properties[0].Initialization = &ast.Literal{Type: ast.Float, Value: "1"}
+		},
+		edited("$n=self::START;", "$n = 1;"),
+	},
+	{
+		"rename function",
+		func(f *ast.File) {
+			helper(f).Name = "assist"
+		},
+		edited("function   helper ( $x )", "function assist($x)"),
+	},
+	{
+		"rename method",
+		func(f *ast.File) {
+			class(f).Methods[1].Name = "value"
+		},
+		edited("public function get() {", "public function value() {"),
+	},
+	{
+		"remove method",
+		func(f *ast.File) {
+			c := class(f)
+			c.Methods = c.Methods[1:]
+		},
+		edited(
+			"class Counter {", "class Counter\n{",
+			"  public function inc( $by=1 ) {\n    $this->n+=$by; // add\n    return $this ;\n  }\n\n", "",
+		),
+	},
+}
+
+func TestFprintFileEdits(t *testing.T) {
+	for _, test := range editTests {
+		got := fprintFile(t, editSource, test.edit)
+		if got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFprintFileRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../test/php-files/*.php")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no test files: %v", err)
+	}
+	for _, name := range files {
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, errs := parser.NewParserFile(name, string(src)).ParseFile()
+		if len(errs) > 0 {
+			t.Errorf("%s: unexpected errors: %v", name, errs)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := FprintFile(&buf, f); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := buf.String(); got != string(src) {
+			t.Errorf("%s: printed file differs from the source", name)
+		}
+	}
+}
+
+func TestFprintFileRenameDeclaration(t *testing.T) {
+	src := "<?php\ninterface I {\n  function f( $a );\n}\nabstract class A {\n  abstract  function f();\n}\n"
+	got := fprintFile(t, src, func(f *ast.File) {
+		f.Nodes[0].(*ast.Interface).Methods[0].Name = "g"
+		f.Nodes[1].(*ast.Class).Methods[0].Name = "g"
+	})
+	want := "<?php\ninterface I {\n  function g($a);\n}\nabstract class A {\n  abstract  function g();\n}\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFprintFileLexerError(t *testing.T) {
+	tests := []string{
+		"<?php\n$a = 1;\n$b = \"abc\n$c = 2;\n",
+		"<?php\nf();\n$b = 'x\n g();",
+		"<?php\n$a = <<<EOT\nabc\n",
+		"<?php\nf(); é \\ g();\n",
+	}
+	for _, src := range tests {
+		f, errs := parser.NewParser(src).ParseFile()
+		if len(errs) == 0 {
+			t.Errorf("%q: no errors", src)
+		}
+		var buf bytes.Buffer
+		if err := FprintFile(&buf, f); err != nil {
+			t.Errorf("%q: %v", src, err)
+			continue
+		}
+		if got := buf.String(); got != src {
+			t.Errorf("got %q, want %q", got, src)
+		}
+	}
+
+	src := "<?php\nf();\n$b = 'x\n g();"
+	f, _ := parser.NewParser(src).ParseFile()
+	f.Nodes[0].(*ast.ExpressionStmt).Expression.(*ast.FunctionCallExpression).FunctionName = &ast.Identifier{Value: "h"}
+	var buf bytes.Buffer
+	if err := FprintFile(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), strings.Replace(src, "f()", "h()", 1); got != want {
+		t.Errorf("edited: got %q, want %q", got, want)
+	}
+}
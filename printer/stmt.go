@@ -0,0 +1,365 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/jxwr/php-parser/ast"
+)
+
+func (p *printer) stmt(s ast.Statement) {
+	if s != nil && p.copied(s) {
+		return
+	}
+	if text, ok := inlineHTML(s); ok {
+		p.html(text)
+		return
+	}
+	switch s := s.(type) {
+	case nil:
+	case *ast.ExpressionStmt:
+		p.expr(s.Expression)
+		p.print(";")
+	case *ast.EchoStmt:
+		p.print("echo ")
+		p.exprList(s.Expressions)
+		p.print(";")
+	case *ast.ReturnStmt:
+		p.keywordStmt("return", s.Expression)
+	case *ast.BreakStmt:
+		p.keywordStmt("break", s.Expression)
+	case *ast.ContinueStmt:
+		p.keywordStmt("continue", s.Expression)
+	case *ast.ThrowStmt:
+		p.keywordStmt("throw", s.Expression)
+	case *ast.ExitStmt:
+		p.print("exit")
+		if s.Expression != nil {
+			p.print("(")
+			p.expr(s.Expression)
+			p.print(")")
+		}
+		p.print(";")
+	case *ast.IncludeStmt:
+		p.expr(&s.Include)
+		p.print(";")
+	case *ast.FunctionCallStmt:
+		p.expr(&s.FunctionCallExpression)
+		p.print(";")
+	case *ast.BadStmt:
+		p.bad(s, "/* bad statement */")
+	case *ast.EmptyStatement:
+		p.print(";")
+	case *ast.GlobalDeclaration:
+		p.print("global ")
+		for i, v := range s.Identifiers {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expr(v)
+		}
+		p.print(";")
+	case *ast.StaticVariableDeclaration:
+		p.staticDeclaration(s)
+	case *ast.ListStatement:
+		p.print("list(")
+		for i, a := range s.Assignees {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.node(a)
+		}
+		p.print(") ", s.Operator, " ")
+		p.expr(s.Value)
+		p.print(";")
+	case *ast.Block:
+		p.block(s)
+	case *ast.IfStmt:
+		p.ifStmt(s)
+	case *ast.WhileStmt:
+		p.print("while (")
+		p.expr(s.Termination)
+		p.print(") ")
+		p.body(s.LoopBlock)
+	case *ast.DoWhileStmt:
+		p.print("do ")
+		p.body(s.LoopBlock)
+		p.print(" while (")
+		p.expr(s.Termination)
+		p.print(");")
+	case *ast.ForStmt:
+		p.print("for (")
+		p.exprList(s.Initialization)
+		p.print("; ")
+		p.exprList(s.Termination)
+		p.print("; ")
+		p.exprList(s.Iteration)
+		p.print(") ")
+		p.body(s.LoopBlock)
+	case *ast.ForeachStmt:
+		p.print("foreach (")
+		p.expr(s.Source)
+		p.print(" as ")
+		if s.Key != nil {
+			if s.KeyByRef {
+				p.print("&")
+			}
+			p.expr(s.Key)
+			p.print(" => ")
+		}
+		if s.ValueByRef {
+			p.print("&")
+		}
+		p.expr(s.Value)
+		p.print(") ")
+		p.body(s.LoopBlock)
+	case *ast.SwitchStmt:
+		p.switchStmt(s)
+	case *ast.TryStmt:
+		p.print("try ")
+		p.block(s.TryBlock)
+		for _, c := range s.CatchStmts {
+			p.print(" ")
+			p.catchStmt(c)
+		}
+		if s.FinallyBlock != nil {
+			p.print(" finally ")
+			p.block(s.FinallyBlock)
+		}
+	case *ast.CatchStmt:
+		p.catchStmt(s)
+	case *ast.DeclareBlock:
+		p.print("declare(", strings.Join(s.Declarations, ", "), ")")
+		if s.Statements == nil {
+			p.print(";")
+			return
+		}
+		p.print(" ")
+		p.block(s.Statements)
+	case *ast.NamespaceStmt:
+		p.print("namespace")
+		if s.Name != "" {
+			p.print(" ", s.Name)
+		}
+		if s.Block == nil {
+			p.print(";")
+			return
+		}
+		p.print(" ")
+		p.block(s.Block)
+	case *ast.UseStmt:
+		p.useStmt(s)
+	case *ast.FunctionStmt:
+		p.doc(s.Doc)
+		p.function(s)
+	case *ast.FunctionDefinition:
+		// the function or method holding the definition writes the ; of a
+		// declaration without a body
+		p.functionDefinition(s)
+	case *ast.Class:
+		p.class(s)
+	case *ast.Interface:
+		p.iface(s)
+	case *ast.Trait:
+		p.trait(s)
+	case *ast.TraitUse:
+		p.traitUse(s)
+	case *ast.Method:
+		p.method(s, false)
+	}
+}
+
+// keywordStmt writes a statement made of a keyword and an optional
+// expression.
+func (p *printer) keywordStmt(keyword string, e ast.Expression) {
+	p.print(keyword)
+	if e != nil {
+		p.print(" ")
+		p.expr(e)
+	}
+	p.print(";")
+}
+
+func (p *printer) block(b *ast.Block) {
+	if b != nil && p.copied(b) {
+		return
+	}
+	p.print("{")
+	p.indent++
+	if b != nil && len(b.Statements) > 0 {
+		open, close, braced := p.braces(b)
+		first, last := b.Statements[0], b.Statements[len(b.Statements)-1]
+		margin, indent := p.margin, p.indent
+		for _, s := range b.Statements {
+			if braced && p.align(s.Pos()) {
+				break
+			}
+		}
+		if doc := p.docOf(first); !braced || !p.gap(open, first.Pos(), doc) {
+			p.newline()
+			p.comments(first, doc)
+		}
+		p.statements(b.Statements)
+		closed := braced && p.gap(last.End(), close, "")
+		if !closed {
+			p.trailingComment(last)
+		}
+		p.margin, p.indent = margin, indent
+		if closed {
+			p.indent--
+			p.print("}")
+			return
+		}
+	}
+	if b != nil {
+		p.closingComments(b.End())
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// body writes the body of a control structure, bracing it if the source
+// did not.
+func (p *printer) body(s ast.Statement) {
+	if b, ok := s.(*ast.Block); ok {
+		p.block(b)
+		return
+	}
+	p.block(&ast.Block{Statements: []ast.Statement{s}})
+}
+
+func (p *printer) ifStmt(s *ast.IfStmt) {
+	p.print("if (")
+	p.expr(s.Condition)
+	p.print(") ")
+	p.body(s.TrueBranch)
+	switch f := s.FalseBranch.(type) {
+	case nil:
+	case *ast.IfStmt:
+		p.print(" else")
+		p.ifStmt(f)
+	case *ast.Block:
+		if len(f.Statements) > 0 {
+			p.print(" else ")
+			p.block(f)
+		}
+	default:
+		p.print(" else ")
+		p.body(f)
+	}
+}
+
+func (p *printer) switchStmt(s *ast.SwitchStmt) {
+	p.print("switch (")
+	p.expr(s.Expression)
+	p.print(") {")
+	p.indent++
+	// the default case keeps its place among the others, which matters
+	// when a case falls through into it
+	defaultAt := len(s.Cases)
+	if s.DefaultCase != nil && s.DefaultCase.Pos().Line > 0 {
+		for i, c := range s.Cases {
+			if c.Pos().Position > s.DefaultCase.Pos().Position {
+				defaultAt = i
+				break
+			}
+		}
+	}
+	for i := 0; i <= len(s.Cases); i++ {
+		if i == defaultAt && s.DefaultCase != nil {
+			p.newline()
+			p.print("default:")
+			p.caseBody(s.DefaultCase)
+		}
+		if i < len(s.Cases) {
+			p.newline()
+			p.print("case ")
+			p.expr(s.Cases[i].Expression)
+			p.print(":")
+			p.caseBody(&s.Cases[i].Block)
+		}
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) caseBody(b *ast.Block) {
+	if len(b.Statements) == 0 {
+		return
+	}
+	p.indent++
+	p.newline()
+	p.comments(b.Statements[0], p.docOf(b.Statements[0]))
+	p.statements(b.Statements)
+	p.trailingComment(b.Statements[len(b.Statements)-1])
+	p.indent--
+}
+
+func (p *printer) catchStmt(c *ast.CatchStmt) {
+	p.print("catch (", strings.Join(c.CatchType, " | "))
+	if c.CatchVar != nil {
+		p.print(" ")
+		p.expr(c.CatchVar)
+	}
+	p.print(") ")
+	p.block(c.CatchBlock)
+}
+
+func (p *printer) staticDeclaration(s *ast.StaticVariableDeclaration) {
+	p.print("static ")
+	first := true
+	for i, d := range s.Declarations {
+		// the parser follows an initialized variable with the variable
+		// itself
+		if i > 0 {
+			if a, ok := s.Declarations[i-1].(*ast.AssignmentExpression); ok && a.Assignee == d {
+				continue
+			}
+		}
+		if !first {
+			p.print(", ")
+		}
+		first = false
+		p.expr(d)
+	}
+	p.print(";")
+}
+
+func (p *printer) useStmt(s *ast.UseStmt) {
+	p.print("use ")
+	p.print(useKeyword(s.Type))
+	if s.Prefix != "" {
+		p.print(s.Prefix, "\\{")
+	}
+	for i, u := range s.Uses {
+		if i > 0 {
+			p.print(", ")
+		}
+		name := u.Name
+		if s.Prefix != "" {
+			if u.Type != s.Type {
+				p.print(useKeyword(u.Type))
+			}
+			name = strings.TrimPrefix(name, s.Prefix+"\\")
+		}
+		p.print(name)
+		if u.Alias != "" {
+			p.print(" as ", u.Alias)
+		}
+	}
+	if s.Prefix != "" {
+		p.print("}")
+	}
+	p.print(";")
+}
+
+func useKeyword(t ast.UseType) string {
+	switch t {
+	case ast.UseFunction:
+		return "function "
+	case ast.UseConst:
+		return "const "
+	}
+	return ""
+}
@@ -1,7 +1,18 @@
 package token
 
+import "fmt"
+
 type Position struct {
 	Line, Column int // The position relative to other characters in the file
 	Position     int // The position in bytes in the file
 	File         string
 }
+
+// String renders the position as file:line:column, omitting the file when
+// it is unknown.
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
@@ -1,6 +1,10 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type Type int
 
@@ -12,12 +16,41 @@ const (
 	MarkerType     // marker for code blocks and groupings, e.g. {, (
 	OperatorType   // operator, e.g. +, ===, $
 	IdentifierType // identifier, e.g. StdClass
-	Significant    = KeywordType | LiteralType | MarkerType | IdentifierType
+	CommentType    // comment, e.g. /* a comment */
+	WhitespaceType // whitespace between other tokens
 
-	CommentType
-	WhitespaceType
+	Significant = KeywordType | LiteralType | MarkerType | IdentifierType
 )
 
+var typeNames = []struct {
+	typ  Type
+	name string
+}{
+	{InvalidType, "invalid"},
+	{KeywordType, "keyword"},
+	{LiteralType, "literal"},
+	{MarkerType, "marker"},
+	{OperatorType, "operator"},
+	{IdentifierType, "identifier"},
+	{CommentType, "comment"},
+	{WhitespaceType, "whitespace"},
+}
+
+// String returns the name of the category, such as "keyword", joining the
+// names of combined categories with "|".
+func (t Type) String() string {
+	var names []string
+	for _, n := range typeNames {
+		if t&n.typ != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return strconv.Itoa(int(t))
+	}
+	return strings.Join(names, "|")
+}
+
 func (t Token) IsType(ty Type) bool {
 	return t.Type()&ty != 0
 }
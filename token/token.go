@@ -132,6 +132,13 @@ const (
 
 	Include
 	Exit
+
+	Trait
+	InsteadOf
+
+	Yield
+
+	Ellipsis
 )
 
 var tokens = []string{
@@ -156,6 +163,7 @@ var tokens = []string{
 
 	Global:       "global",
 	Return:       "Return",
+	Yield:        "yield",
 	ArgumentType: "Function Argument Type",
 	ArgumentName: "Function Argument Name",
 	Comma:        "Function Argument Separator",
@@ -185,6 +193,12 @@ var tokens = []string{
 	Break:      "break",
 	Null:       "null",
 
+	EndIf:      "endif",
+	EndFor:     "endfor",
+	EndForeach: "endforeach",
+	EndWhile:   "endwhile",
+	EndSwitch:  "endswitch",
+
 	Comment: "/* */",
 
 	Try:     "try",
@@ -199,9 +213,12 @@ var tokens = []string{
 	Protected:   "Protected",
 	Public:      "Public",
 	Interface:   "Interface",
+	Trait:       "trait",
+	InsteadOf:   "insteadof",
 	Implements:  "implements",
 	Extends:     "extends",
 	NewOperator: "new",
+	Var:         "var",
 
 	ShellCommand:   "`",
 	StringLiteral:  "string-literal",
@@ -229,19 +246,23 @@ var tokens = []string{
 	WrittenOrOperator:  "logical-or",
 	CastOperator:       "(type)",
 
-	List:                     "list",
-	Array:                    "array",
-	ArrayKeyOperator:         "=>",
-	ArrayLookupOperatorLeft:  "[",
-	ArrayLookupOperatorRight: "]",
-	BitwiseShiftOperator:     "<<>>",
-	EqualityOperator:         "!===",
-	AmpersandOperator:        "&",
-	BitwiseXorOperator:       "^",
-	BitwiseOrOperator:        "|",
-	BitwiseNotOperator:       "~",
-	TernaryOperator1:         "?",
-	TernaryOperator2:         ":",
+	List:                      "list",
+	Array:                     "array",
+	ArrayKeyOperator:          "=>",
+	ArrayLookupOperatorLeft:   "[",
+	ArrayLookupOperatorRight:  "]",
+	BitwiseShiftOperator:      "<<>>",
+	EqualityOperator:          "!===",
+	StrongEqualityOperator:    "===",
+	StrongNotEqualityOperator: "!==",
+	NotEqualityOperator:       "!=",
+	AmpersandOperator:         "&",
+	BitwiseXorOperator:        "^",
+	BitwiseOrOperator:         "|",
+	BitwiseNotOperator:        "~",
+	Ellipsis:                  "...",
+	TernaryOperator1:          "?",
+	TernaryOperator2:          ":",
 
 	Include: "include",
 	Exit:    "exit",
@@ -269,6 +290,8 @@ var TokenMap = map[string]Token{
 	"const":        Const,
 	"abstract":     Abstract,
 	"interface":    Interface,
+	"trait":        Trait,
+	"insteadof":    InsteadOf,
 	"implements":   Implements,
 	"extends":      Extends,
 	"new":          NewOperator,
@@ -299,6 +322,7 @@ var TokenMap = map[string]Token{
 	"self":         Self,
 	"parent":       Parent,
 	"return":       Return,
+	"yield":        Yield,
 	"{":            BlockBegin,
 	"}":            BlockEnd,
 	";":            StatementEnd,
@@ -391,6 +415,7 @@ var TokenMap = map[string]Token{
 	"&":   AmpersandOperator,
 	"^":   BitwiseXorOperator,
 	"~":   BitwiseNotOperator,
+	"...": Ellipsis,
 	"|":   BitwiseOrOperator,
 	"<<":  BitwiseShiftOperator,
 	">>":  BitwiseShiftOperator,
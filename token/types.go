@@ -10,6 +10,7 @@ func (t Token) Type() Type {
 
 var tokenTypes = map[Token]Type{
 	HTML:     LiteralType,
+	PHP:      KeywordType,
 	PHPBegin: KeywordType,
 	PHPEnd:   KeywordType,
 	PHPToken: KeywordType,
@@ -26,6 +27,7 @@ var tokenTypes = map[Token]Type{
 	Final:     KeywordType,
 	Global:    KeywordType,
 	Return:    KeywordType,
+	Yield:     KeywordType,
 	Namespace: KeywordType,
 	Use:       KeywordType,
 	Echo:      KeywordType,
@@ -33,6 +35,8 @@ var tokenTypes = map[Token]Type{
 
 	FunctionName:     IdentifierType,
 	TypeHint:         IdentifierType,
+	ArgumentType:     IdentifierType,
+	ArgumentName:     IdentifierType,
 	VariableOperator: OperatorType,
 
 	Comma:        MarkerType,
@@ -60,6 +64,11 @@ var tokenTypes = map[Token]Type{
 	Catch:      KeywordType,
 	Finally:    KeywordType,
 	Throw:      KeywordType,
+	EndIf:      KeywordType,
+	EndFor:     KeywordType,
+	EndForeach: KeywordType,
+	EndWhile:   KeywordType,
+	EndSwitch:  KeywordType,
 
 	OpenParen:  MarkerType,
 	CloseParen: MarkerType,
@@ -74,9 +83,12 @@ var tokenTypes = map[Token]Type{
 	Protected:   KeywordType,
 	Public:      KeywordType,
 	Interface:   KeywordType,
+	Trait:       KeywordType,
+	InsteadOf:   KeywordType,
 	Implements:  KeywordType,
 	Extends:     KeywordType,
 	NewOperator: KeywordType,
+	Var:         KeywordType,
 
 	ShellCommand:   LiteralType,
 	StringLiteral:  LiteralType,
@@ -109,14 +121,18 @@ var tokenTypes = map[Token]Type{
 	ArrayLookupOperatorLeft:  MarkerType,
 	ArrayLookupOperatorRight: MarkerType,
 
-	BitwiseShiftOperator: OperatorType,
-	EqualityOperator:     OperatorType,
-	AmpersandOperator:    OperatorType,
-	BitwiseXorOperator:   OperatorType,
-	BitwiseOrOperator:    OperatorType,
-	BitwiseNotOperator:   OperatorType,
-	TernaryOperator1:     OperatorType,
-	TernaryOperator2:     OperatorType,
+	BitwiseShiftOperator:      OperatorType,
+	EqualityOperator:          OperatorType,
+	StrongEqualityOperator:    OperatorType,
+	StrongNotEqualityOperator: OperatorType,
+	NotEqualityOperator:       OperatorType,
+	AmpersandOperator:         OperatorType,
+	BitwiseXorOperator:        OperatorType,
+	BitwiseOrOperator:         OperatorType,
+	BitwiseNotOperator:        OperatorType,
+	Ellipsis:                  OperatorType,
+	TernaryOperator1:          OperatorType,
+	TernaryOperator2:          OperatorType,
 
 	Include: KeywordType,
 	Exit:    KeywordType,